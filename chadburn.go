@@ -15,33 +15,71 @@ var build = "2025-03-16T19:59:32Z"
 
 const logFormat = "%{color}%{time:2006-01-02 15:04:05.000} %{shortfile} ▶ %{level}%{color:reset} %{message}"
 
-func buildLogger() core.Logger {
-	stdout := logging.NewLogBackend(os.Stdout, "", 0)
-	// Set the backends to be used.
-	logging.SetBackend(stdout)
-	logging.SetFormatter(logging.MustStringFormatter(logFormat))
+// options are the application options, shared by all the commands
+type options struct {
+	LogFormat string `long:"log-format" env:"CHADBURN_LOG_FORMAT" description:"format of the log lines" choice:"text" choice:"json" default:"text"`
+}
 
+func buildLogger(format string) core.Logger {
 	// set default level
-	logging.SetLevel(logging.INFO, "chadburn")
+	level := logging.INFO
 
 	level_string, got_env_var := os.LookupEnv("CHADBURN_LOG_LEVEL")
 	if got_env_var {
-		level, err := logging.LogLevel(level_string)
+		l, err := logging.LogLevel(level_string)
 		if err == nil {
-			logging.SetLevel(level, "chadburn")
+			level = l
 		} else {
 			fmt.Println("WARNING: could not interpret", level_string, "as log level: ", err)
 		}
 	}
 
+	if format == "json" {
+		return cli.NewJSONLogger(os.Stdout, level)
+	}
+
+	stdout := logging.NewLogBackend(os.Stdout, "", 0)
+	// Set the backends to be used.
+	logging.SetBackend(stdout)
+	logging.SetFormatter(logging.MustStringFormatter(logFormat))
+	logging.SetLevel(level, "chadburn")
+
 	return logging.MustGetLogger("chadburn")
 }
 
 func main() {
-	logger := buildLogger()
+	opts := &options{}
+	daemon := &cli.DaemonCommand{}
+	validate := &cli.ValidateCommand{}
+	history := &cli.HistoryCommand{}
+	run := &cli.RunCommand{}
+	list := &cli.ListCommand{}
+	next := &cli.NextCommand{}
+	convert := &cli.ConvertCommand{}
+
 	parser := flags.NewNamedParser("chadburn", flags.Default)
-	parser.AddCommand("daemon", "daemon process", "", &cli.DaemonCommand{Logger: logger})
-	parser.AddCommand("validate", "validates the config file", "", &cli.ValidateCommand{Logger: logger})
+	parser.AddGroup("Application Options", "", opts)
+	parser.AddCommand("daemon", "daemon process", "", daemon)
+	parser.AddCommand("validate", "validates the config file", "", validate)
+	parser.AddCommand("history", "shows the recorded executions", "", history)
+	parser.AddCommand("run", "runs a job now and exits with its exit code", "", run)
+	parser.AddCommand("list", "lists the configured jobs", "", list)
+	parser.AddCommand("next", "shows when the jobs will run next", "", next)
+
+	config, _ := parser.AddCommand("config", "manages the config file", "", &struct{}{})
+	config.AddCommand("convert", "converts the config file to YAML, TOML or INI", "", convert)
+
+	// the logger depends on the options, so it is built once they are parsed
+	parser.CommandHandler = func(cmd flags.Commander, args []string) error {
+		logger := buildLogger(opts.LogFormat)
+		daemon.Logger, validate.Logger, history.Logger, run.Logger = logger, logger, logger, logger
+		list.Logger, next.Logger, convert.Logger = logger, logger, logger
+		if cmd == nil {
+			return nil
+		}
+
+		return cmd.Execute(args)
+	}
 
 	if _, err := parser.Parse(); err != nil {
 		if _, ok := err.(*flags.Error); ok {
@@ -49,6 +87,11 @@ func main() {
 			fmt.Printf("\nBuild information\n  commit: %s\n  date:%s\n", version, build)
 		}
 
+		// the run command exits with the exit code of the job
+		if code, ok := core.GetExitCode(err); ok {
+			os.Exit(code)
+		}
+
 		os.Exit(1)
 	}
 }
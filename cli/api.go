@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+const apiPrefix = "/api/v1/"
+
+// apiHandler exposes the scheduler through a small REST API
+type apiHandler struct {
+	config    *Config
+	scheduler *core.Scheduler
+	token     string
+	logger    core.Logger
+}
+
+// apiJob is the representation of a job returned by the API
+type apiJob struct {
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Schedule string     `json:"schedule"`
+	Command  string     `json:"command"`
+	Paused   bool       `json:"paused"`
+	Running  int32      `json:"running"`
+	Next     *time.Time `json:"next,omitempty"`
+	Prev     *time.Time `json:"prev,omitempty"`
+}
+
+// apiExecution is returned when a job is triggered manually
+type apiExecution struct {
+	Job         string `json:"job"`
+	ExecutionID string `json:"execution_id"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func newAPIHandler(c *Config, token string, l core.Logger) *apiHandler {
+	return &apiHandler{config: c, scheduler: c.sh, token: token, logger: l}
+}
+
+// ServeHTTP routes the requests to:
+//
+//	GET    /api/v1/jobs
+//	GET    /api/v1/jobs/{name}
+//	DELETE /api/v1/jobs/{name}
+//	POST   /api/v1/jobs/{name}/run
+//	POST   /api/v1/jobs/{name}/pause
+//	POST   /api/v1/jobs/{name}/resume
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		h.writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+	if parts[0] != "jobs" {
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch len(parts) {
+	case 1:
+		if r.Method != http.MethodGet {
+			h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		h.listJobs(w)
+	case 2:
+		j := h.scheduler.GetJob(parts[1])
+		if j == nil {
+			h.writeError(w, http.StatusNotFound, core.ErrJobNotFound.Error())
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			h.writeJSON(w, http.StatusOK, h.buildJob(j))
+		case http.MethodDelete:
+			h.removeJob(w, j)
+		default:
+			h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case 3:
+		if r.Method != http.MethodPost {
+			h.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		j := h.scheduler.GetJob(parts[1])
+		if j == nil {
+			h.writeError(w, http.StatusNotFound, core.ErrJobNotFound.Error())
+			return
+		}
+
+		h.jobAction(w, j, parts[2])
+	default:
+		h.writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *apiHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+
+	expected := []byte("Bearer " + h.token)
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) == 1
+}
+
+func (h *apiHandler) listJobs(w http.ResponseWriter) {
+	jobs := make([]*apiJob, 0)
+	for _, j := range h.scheduler.GetJobs() {
+		jobs = append(jobs, h.buildJob(j))
+	}
+
+	h.writeJSON(w, http.StatusOK, jobs)
+}
+
+func (h *apiHandler) removeJob(w http.ResponseWriter, j core.Job) {
+	if err := h.config.removeJob(j); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *apiHandler) jobAction(w http.ResponseWriter, j core.Job, action string) {
+	var err error
+	switch action {
+	case "run":
+		h.logger.Noticef("Job %q triggered through the API", j.GetName())
+		e := h.scheduler.RunJob(j)
+		h.writeJSON(w, http.StatusAccepted, &apiExecution{Job: j.GetName(), ExecutionID: e.ID})
+		return
+	case "pause":
+		err = h.scheduler.PauseJob(j)
+	case "resume":
+		err = h.scheduler.ResumeJob(j)
+	default:
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, h.buildJob(j))
+}
+
+func (h *apiHandler) buildJob(j core.Job) *apiJob {
+	job := &apiJob{
+		Name:     j.GetName(),
+		Type:     core.JobType(j),
+		Schedule: j.GetSchedule(),
+		Command:  j.GetCommand(),
+		Paused:   h.scheduler.IsPaused(j),
+		Running:  j.Running(),
+	}
+
+	next, prev := h.scheduler.GetJobTimes(j)
+	if !next.IsZero() {
+		job.Next = &next
+	}
+
+	if !prev.IsZero() {
+		job.Prev = &prev
+	}
+
+	return job
+}
+
+func (h *apiHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Errorf("API error encoding response: %v", err)
+	}
+}
+
+func (h *apiHandler) writeError(w http.ResponseWriter, status int, msg string) {
+	h.writeJSON(w, status, &apiError{Error: msg})
+}
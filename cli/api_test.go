@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	. "gopkg.in/check.v1"
+)
+
+type SuiteAPI struct {
+	config    *Config
+	scheduler *core.Scheduler
+	job       *LocalJobConfig
+	server    *httptest.Server
+}
+
+var _ = Suite(&SuiteAPI{})
+
+func (s *SuiteAPI) SetUpTest(c *C) {
+	s.scheduler = core.NewScheduler(&TestLogger{})
+	s.config = NewConfig(&TestLogger{})
+	s.config.sh = s.scheduler
+
+	s.job = &LocalJobConfig{}
+	s.job.Name = "backup"
+	s.job.Schedule = "@hourly"
+	s.job.Command = "true"
+	s.config.LocalJobs["backup"] = s.job
+	c.Assert(s.scheduler.AddJob(s.job), IsNil)
+
+	s.server = httptest.NewServer(newAPIHandler(s.config, "", &TestLogger{}))
+}
+
+func (s *SuiteAPI) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *SuiteAPI) do(c *C, method, path string) *http.Response {
+	req, err := http.NewRequest(method, s.server.URL+path, nil)
+	c.Assert(err, IsNil)
+
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	return resp
+}
+
+func (s *SuiteAPI) TestListJobs(c *C) {
+	resp := s.do(c, http.MethodGet, "/api/v1/jobs")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	var jobs []apiJob
+	c.Assert(json.NewDecoder(resp.Body).Decode(&jobs), IsNil)
+	c.Assert(jobs, HasLen, 1)
+	c.Assert(jobs[0].Name, Equals, "backup")
+	c.Assert(jobs[0].Type, Equals, jobLocal)
+	c.Assert(jobs[0].Schedule, Equals, "@hourly")
+}
+
+func (s *SuiteAPI) TestGetJobNotFound(c *C) {
+	resp := s.do(c, http.MethodGet, "/api/v1/jobs/missing")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *SuiteAPI) TestPauseResumeJob(c *C) {
+	resp := s.do(c, http.MethodPost, "/api/v1/jobs/backup/pause")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	var job apiJob
+	c.Assert(json.NewDecoder(resp.Body).Decode(&job), IsNil)
+	c.Assert(job.Paused, Equals, true)
+	c.Assert(s.scheduler.IsPaused(s.job), Equals, true)
+
+	resp = s.do(c, http.MethodPost, "/api/v1/jobs/backup/resume")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(s.scheduler.IsPaused(s.job), Equals, false)
+}
+
+func (s *SuiteAPI) TestRunJob(c *C) {
+	resp := s.do(c, http.MethodPost, "/api/v1/jobs/backup/run")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusAccepted)
+
+	var e apiExecution
+	c.Assert(json.NewDecoder(resp.Body).Decode(&e), IsNil)
+	c.Assert(e.Job, Equals, "backup")
+	c.Assert(e.ExecutionID, Not(Equals), "")
+
+	time.Sleep(100 * time.Millisecond)
+}
+
+func (s *SuiteAPI) TestRunJobWrongMethod(c *C) {
+	resp := s.do(c, http.MethodGet, "/api/v1/jobs/backup/run")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusMethodNotAllowed)
+}
+
+func (s *SuiteAPI) TestRemoveJob(c *C) {
+	resp := s.do(c, http.MethodDelete, "/api/v1/jobs/backup")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNoContent)
+	c.Assert(s.scheduler.GetJob("backup"), IsNil)
+	c.Assert(s.config.LocalJobs, HasLen, 0)
+}
+
+func (s *SuiteAPI) TestToken(c *C) {
+	s.server.Close()
+	s.server = httptest.NewServer(newAPIHandler(s.config, "secret", &TestLogger{}))
+
+	resp := s.do(c, http.MethodGet, "/api/v1/jobs")
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusUnauthorized)
+
+	req, err := http.NewRequest(http.MethodGet, s.server.URL+"/api/v1/jobs", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+}
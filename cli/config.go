@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
 	"github.com/PremoWeb/Chadburn/core"
 	"github.com/PremoWeb/Chadburn/middlewares"
 
 	defaults "github.com/mcuadros/go-defaults"
 	gcfg "gopkg.in/gcfg.v1"
+	warnings "gopkg.in/warnings.v0"
 )
 
 const (
@@ -14,28 +20,62 @@ const (
 	jobServiceRun = "job-service-run"
 	jobLocal      = "job-local"
 	jobLifecycle  = "job-lifecycle"
+
+	// webhookSection is the type of the sections of the named webhooks
+	webhookSection = "webhook"
 )
 
 // Config contains the configuration
 type Config struct {
 	Global struct {
-		middlewares.SlackConfig  `mapstructure:",squash"`
-		middlewares.SaveConfig   `mapstructure:",squash"`
-		middlewares.MailConfig   `mapstructure:",squash"`
-		middlewares.GotifyConfig `mapstructure:",squash"`
+		// Include are glob patterns of other configuration files or
+		// directories to read, relative to the directory of the file
+		Include []string `gcfg:"include" mapstructure:"include"`
+		// Timezone is the IANA time zone of the schedules, the local time
+		// zone of the host or container by default
+		Timezone string `gcfg:"timezone" mapstructure:"timezone"`
+		// EnableSeconds allows an optional seconds field in the schedules
+		EnableSeconds bool `gcfg:"enable-seconds" mapstructure:"enable-seconds"`
+
+		middlewares.NotifyConfig  `mapstructure:",squash"`
+		middlewares.PingConfig    `mapstructure:",squash"`
+		middlewares.SlackConfig   `mapstructure:",squash"`
+		middlewares.SaveConfig    `mapstructure:",squash"`
+		middlewares.MailConfig    `mapstructure:",squash"`
+		middlewares.GotifyConfig  `mapstructure:",squash"`
+		middlewares.TeamsConfig   `mapstructure:",squash"`
+		middlewares.WebhookConfig `mapstructure:",squash"`
+		middlewares.HistoryConfig `mapstructure:",squash"`
 	}
 	ExecJobs      map[string]*ExecJobConfig      `gcfg:"job-exec" mapstructure:"job-exec,squash"`
 	RunJobs       map[string]*RunJobConfig       `gcfg:"job-run" mapstructure:"job-run,squash"`
 	ServiceJobs   map[string]*RunServiceConfig   `gcfg:"job-service-run" mapstructure:"job-service-run,squash"`
 	LocalJobs     map[string]*LocalJobConfig     `gcfg:"job-local" mapstructure:"job-local,squash"`
 	LifecycleJobs map[string]*LifecycleJobConfig `gcfg:"job-lifecycle" mapstructure:"job-lifecycle,squash"`
+	// Webhooks are the [webhook "name"] sections, sent besides the webhook of
+	// the global section or of the job
+	Webhooks      map[string]*middlewares.NamedWebhookConfig `gcfg:"webhook" mapstructure:"webhook,squash"`
 	sh            *core.Scheduler
 	dockerHandler *DockerHandler
 	// New official Docker handler
 	officialDockerHandler *OfficialDockerHandler
 	// Flag to indicate if we're using the official Docker client
 	useOfficialDocker bool
+	disableDocker     bool
 	logger            core.Logger
+	// dockerPoll is how often the jobs of the Docker labels are resynchronized
+	// besides the container events, never if zero
+	dockerPoll time.Duration
+	// labelOptions select the Docker labels defining the jobs
+	labelOptions LabelOptions
+	// sources are the files the jobs are defined in, by job name
+	sources map[string]string
+	// paths are the files, directories and glob patterns the configuration
+	// was read from
+	paths []string
+	// mutex serializes the changes to the jobs, made by Docker label updates
+	// and by configuration reloads
+	mutex *sync.Mutex
 }
 
 func NewConfig(logger core.Logger) *Config {
@@ -46,16 +86,45 @@ func NewConfig(logger core.Logger) *Config {
 	c.ServiceJobs = make(map[string]*RunServiceConfig)
 	c.LocalJobs = make(map[string]*LocalJobConfig)
 	c.LifecycleJobs = make(map[string]*LifecycleJobConfig)
+	c.Webhooks = make(map[string]*middlewares.NamedWebhookConfig)
 	c.logger = logger
+	c.sources = make(map[string]string)
+	c.dockerPoll = defaultDockerPoll
+	c.labelOptions = LabelOptions{LabelPrefix: labelPrefix}
+	c.mutex = &sync.Mutex{}
 	defaults.SetDefaults(c)
 	return c
 }
 
-// BuildFromFile builds a scheduler using the config from a file
+// BuildFromFile builds a scheduler using the config from a file, in INI,
+// YAML or TOML format depending on its extension. The filename can also be a
+// directory, all the configuration files in it are read, and the files set
+// in the include option are read too.
 func BuildFromFile(filename string, logger core.Logger) (*Config, error) {
+	l := newConfigLoader(filename, logger)
+	err := l.loadPath(filename)
+	if err == nil && len(l.warnings) > 0 {
+		err = warnings.List{Warnings: l.warnings}
+	}
+
+	return l.config, err
+}
+
+// readConfigFile reads a single configuration file, without its includes
+func readConfigFile(filename string, logger core.Logger) (*Config, error) {
 	c := NewConfig(logger)
-	err := gcfg.ReadFileInto(c, filename)
-	return c, err
+	format := configFormat(filename)
+	if format == formatINI {
+		err := gcfg.ReadFileInto(c, filename)
+		return c, err
+	}
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.decode(format, src)
 }
 
 // BuildFromString builds a scheduler using the config from a string
@@ -69,19 +138,31 @@ func BuildFromString(config string, logger core.Logger) (*Config, error) {
 
 // Call this only once at app init
 func (c *Config) InitializeApp(dd bool) error {
-	c.sh = core.NewScheduler(c.logger)
+	// the Docker handlers update the jobs of the labels as soon as created
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.CheckDependencies(); err != nil {
+		return err
+	}
+
+	opts, err := c.schedulerOptions()
+	if err != nil {
+		return err
+	}
+
+	c.sh = core.NewSchedulerWithOptions(c.logger, opts)
 	c.buildSchedulerMiddlewares(c.sh)
+	c.disableDocker = dd
 
 	if !dd {
-		var err error
-
 		// Try to use the official Docker client first
-		c.officialDockerHandler, err = NewOfficialDockerHandler(c, c.logger)
+		c.officialDockerHandler, err = NewOfficialDockerHandler(c, c.logger, c.dockerPoll, c.labelOptions)
 		if err != nil {
 			c.logger.Warningf("Failed to initialize official Docker client: %v. Falling back to legacy client.", err)
 
 			// Fall back to the legacy Docker client
-			c.dockerHandler, err = NewDockerHandler(c, c.logger)
+			c.dockerHandler, err = NewDockerHandler(c, c.logger, c.dockerPoll, c.labelOptions)
 			if err != nil {
 				return err
 			}
@@ -100,16 +181,8 @@ func (c *Config) InitializeApp(dd bool) error {
 
 		for name, j := range c.ExecJobs {
 			defaults.SetDefaults(j)
-			if c.useOfficialDocker {
-				// We can't use the official client for ExecJobs yet
-				// This will need to be updated in a future PR
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					return err
-				}
-				j.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				j.Client = c.dockerHandler.GetInternalDockerClient()
+			if j.Client, err = c.internalDockerClient(); err != nil {
+				return err
 			}
 			j.Name = name
 			j.buildMiddlewares()
@@ -118,16 +191,8 @@ func (c *Config) InitializeApp(dd bool) error {
 
 		for name, j := range c.RunJobs {
 			defaults.SetDefaults(j)
-			if c.useOfficialDocker {
-				// We can't use the official client for RunJobs yet
-				// This will need to be updated in a future PR
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					return err
-				}
-				j.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				j.Client = c.dockerHandler.GetInternalDockerClient()
+			if j.Client, err = c.internalDockerClient(); err != nil {
+				return err
 			}
 			j.Name = name
 			j.buildMiddlewares()
@@ -137,16 +202,8 @@ func (c *Config) InitializeApp(dd bool) error {
 		for name, j := range c.ServiceJobs {
 			defaults.SetDefaults(j)
 			j.Name = name
-			if c.useOfficialDocker {
-				// We can't use the official client for ServiceJobs yet
-				// This will need to be updated in a future PR
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					return err
-				}
-				j.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				j.Client = c.dockerHandler.GetInternalDockerClient()
+			if j.Client, err = c.internalDockerClient(); err != nil {
+				return err
 			}
 			j.buildMiddlewares()
 			c.sh.AddJob(j)
@@ -154,18 +211,8 @@ func (c *Config) InitializeApp(dd bool) error {
 
 		for name, j := range c.LifecycleJobs {
 			defaults.SetDefaults(j)
-			// Set the Docker client based on which handler we're using
-			if c.useOfficialDocker {
-				// We can't use the official client for LifecycleJobs yet
-				// This will need to be updated in a future PR
-				var err error
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					return err
-				}
-				j.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				j.Client = c.dockerHandler.GetInternalDockerClient()
+			if j.Client, err = c.internalDockerClient(); err != nil {
+				return err
 			}
 			j.Name = name
 			j.buildMiddlewares()
@@ -184,24 +231,161 @@ func (c *Config) InitializeApp(dd bool) error {
 	return nil
 }
 
+// buildJob prepares the job with the given name to be run outside of the
+// daemon, with its middlewares and a scheduler with the global ones. The
+// Docker client is only requested for the jobs needing it.
+func (c *Config) buildJob(name string, client func() (core.DockerClient, error)) (core.Job, *core.Scheduler, error) {
+	opts, err := c.schedulerOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sh := core.NewSchedulerWithOptions(c.logger, opts)
+	c.buildSchedulerMiddlewares(sh)
+
+	if j, ok := c.LocalJobs[name]; ok {
+		defaults.SetDefaults(j)
+		j.Name = name
+		j.buildMiddlewares()
+		return j, sh, nil
+	}
+
+	if j, ok := c.ExecJobs[name]; ok {
+		defaults.SetDefaults(j)
+		if j.Client, err = client(); err != nil {
+			return nil, nil, fmt.Errorf("job %s needs Docker: %s", c.describeJob(name), err)
+		}
+		j.Name = name
+		j.buildMiddlewares()
+		return j, sh, nil
+	}
+
+	if j, ok := c.RunJobs[name]; ok {
+		defaults.SetDefaults(j)
+		if j.Client, err = client(); err != nil {
+			return nil, nil, fmt.Errorf("job %s needs Docker: %s", c.describeJob(name), err)
+		}
+		j.Name = name
+		j.buildMiddlewares()
+		return j, sh, nil
+	}
+
+	if j, ok := c.ServiceJobs[name]; ok {
+		defaults.SetDefaults(j)
+		if j.Client, err = client(); err != nil {
+			return nil, nil, fmt.Errorf("job %s needs Docker: %s", c.describeJob(name), err)
+		}
+		j.Name = name
+		j.buildMiddlewares()
+		return j, sh, nil
+	}
+
+	if j, ok := c.LifecycleJobs[name]; ok {
+		defaults.SetDefaults(j)
+		if j.Client, err = client(); err != nil {
+			return nil, nil, fmt.Errorf("job %s needs Docker: %s", c.describeJob(name), err)
+		}
+		j.Name = name
+		j.buildMiddlewares()
+		return j, sh, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown job %q", name)
+}
+
+// schedulerOptions returns the options of the scheduler set in the [global]
+// section
+func (c *Config) schedulerOptions() (core.SchedulerOptions, error) {
+	opts := core.SchedulerOptions{Seconds: c.Global.EnableSeconds}
+	if c.Global.Timezone != "" {
+		location, err := time.LoadLocation(c.Global.Timezone)
+		if err != nil {
+			return opts, fmt.Errorf("invalid timezone %q: %s", c.Global.Timezone, err)
+		}
+
+		opts.Location = location
+	}
+
+	return opts, nil
+}
+
+// CheckDependencies returns an error if the dependencies of the jobs contain
+// a cycle
+func (c *Config) CheckDependencies() error {
+	var jobs []core.Job
+	for name, j := range c.ExecJobs {
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.RunJobs {
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.ServiceJobs {
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.LocalJobs {
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	return core.CheckDependencies(jobs)
+}
+
+// internalDockerClient returns the Docker client used by the jobs, the
+// DockerHandler is created only once since it starts watching Docker
+func (c *Config) internalDockerClient() (core.DockerClient, error) {
+	if c.useOfficialDocker {
+		return c.officialDockerHandler.dockerClient, nil
+	}
+
+	if c.dockerHandler == nil {
+		h, err := NewDockerHandler(c, c.logger, c.dockerPoll, c.labelOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		c.dockerHandler = h
+	}
+
+	return c.dockerHandler.GetInternalDockerClient(), nil
+}
+
 func (c *Config) buildSchedulerMiddlewares(sh *core.Scheduler) {
+	sh.Use(middlewares.NewNotify(&c.Global.NotifyConfig))
+	sh.Use(middlewares.NewPing(&c.Global.PingConfig))
 	sh.Use(middlewares.NewSlack(&c.Global.SlackConfig))
 	sh.Use(middlewares.NewSave(&c.Global.SaveConfig))
 	sh.Use(middlewares.NewMail(&c.Global.MailConfig))
 	sh.Use(middlewares.NewGotify(&c.Global.GotifyConfig))
+	sh.Use(middlewares.NewTeams(&c.Global.TeamsConfig))
+	sh.Use(middlewares.NewWebhook(&c.Global.WebhookConfig))
+	sh.Use(middlewares.NewWebhooks(c.Webhooks))
+	sh.Use(middlewares.NewHistory(&c.Global.HistoryConfig))
 }
 
 func (c *Config) dockerLabelsUpdate(labels map[string]map[string]string) {
-	// If labels is nil or empty, this might be due to a connection issue
-	// Don't de-register jobs in this case to prevent thrashing
-	if labels == nil || len(labels) == 0 {
+	// If labels is nil, this might be due to a connection issue
+	// Don't de-register jobs in this case to prevent thrashing, an empty
+	// map means that no container has jobs anymore
+	if labels == nil {
 		c.logger.Debugf("No labels received, skipping update to prevent job de-registration")
 		return
 	}
 
-	// Get the current labels
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Get the current labels, on error the running jobs are kept
 	var parsedLabelConfig Config
-	parsedLabelConfig.buildFromDockerLabels(labels)
+	if err := parsedLabelConfig.buildFromDockerLabels(labels); err != nil {
+		c.logger.Errorf("Cannot read the jobs of the Docker labels: %s", err)
+		return
+	}
 
 	// -- Refresh ExecJobs --
 
@@ -212,83 +396,166 @@ func (c *Config) dockerLabelsUpdate(labels map[string]map[string]string) {
 			continue
 		}
 
-		found := false
-		for newJobsName, newJob := range parsedLabelConfig.ExecJobs {
-			// Check if the schedule has changed
-			if name == newJobsName {
-				found = true
-				// There is a slight race condition were a job can be canceled / restarted with different params
-				// so, lets take care of it by simply restarting
-				// For the hash to work properly, we must fill the fields before calling it
-				defaults.SetDefaults(newJob)
-
-				// Set the Docker client based on which handler we're using
-				if c.useOfficialDocker {
-					// We can't use the official client for ExecJobs yet
-					// This will need to be updated in a future PR
-					var err error
-					c.dockerHandler, err = NewDockerHandler(c, c.logger)
-					if err != nil {
-						c.logger.Errorf("Failed to create Docker handler: %v", err)
-						continue
-					}
-					newJob.Client = c.dockerHandler.GetInternalDockerClient()
-				} else {
-					newJob.Client = c.dockerHandler.GetInternalDockerClient()
-				}
-
-				newJob.Name = newJobsName
-				if newJob.Hash() != j.Hash() {
-					// Remove from the scheduler
-					c.sh.RemoveJob(j)
-					// Add the job back to the scheduler
-					newJob.buildMiddlewares()
-					c.sh.AddJob(newJob)
-					// Update the job config
-					c.ExecJobs[name] = newJob
-				}
-				break
-			}
-		}
+		newJob, found := parsedLabelConfig.ExecJobs[name]
 		if !found {
 			// Remove the job
 			c.sh.RemoveJob(j)
 			delete(c.ExecJobs, name)
+			continue
 		}
+
+		// For the hash to work properly, we must fill the fields before calling it
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		if configHash(newJob) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		newJob.Client = client
+		newJob.buildMiddlewares()
+		c.replaceJob(j, newJob, true)
+		c.ExecJobs[name] = newJob
 	}
 
-	// Check for aditions
-	for newJobsName, newJob := range parsedLabelConfig.ExecJobs {
-		found := false
-		for name := range c.ExecJobs {
-			if name == newJobsName {
-				found = true
-				break
-			}
+	// Check for aditions, the jobs of the configuration file are kept
+	for name, newJob := range parsedLabelConfig.ExecJobs {
+		if _, found := c.ExecJobs[name]; found {
+			continue
+		}
+
+		defaults.SetDefaults(newJob)
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
 		}
+
+		newJob.Client = client
+		newJob.Name = name
+		newJob.buildMiddlewares()
+		c.replaceJob(nil, newJob, false)
+		c.ExecJobs[name] = newJob
+	}
+
+	// -- Refresh RunJobs --
+
+	// Calculate the delta
+	for name, j := range c.RunJobs {
+		// this prevents deletion of jobs that were added by reading a configuration file
+		if !j.FromDockerLabel {
+			continue
+		}
+
+		newJob, found := parsedLabelConfig.RunJobs[name]
 		if !found {
-			defaults.SetDefaults(newJob)
-
-			// Set the Docker client based on which handler we're using
-			if c.useOfficialDocker {
-				// We can't use the official client for ExecJobs yet
-				// This will need to be updated in a future PR
-				var err error
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					c.logger.Errorf("Failed to create Docker handler: %v", err)
-					continue
-				}
-				newJob.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				newJob.Client = c.dockerHandler.GetInternalDockerClient()
-			}
+			// Remove the job
+			c.sh.RemoveJob(j)
+			delete(c.RunJobs, name)
+			continue
+		}
+
+		// For the hash to work properly, we must fill the fields before calling it
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		if configHash(newJob) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		newJob.Client = client
+		newJob.buildMiddlewares()
+		c.replaceJob(j, newJob, true)
+		c.RunJobs[name] = newJob
+	}
+
+	// Check for aditions, the jobs of the configuration file are kept
+	for name, newJob := range parsedLabelConfig.RunJobs {
+		if _, found := c.RunJobs[name]; found {
+			continue
+		}
 
-			newJob.Name = newJobsName
-			newJob.buildMiddlewares()
-			c.sh.AddJob(newJob)
-			c.ExecJobs[newJobsName] = newJob
+		defaults.SetDefaults(newJob)
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
 		}
+
+		newJob.Client = client
+		newJob.Name = name
+		newJob.buildMiddlewares()
+		c.replaceJob(nil, newJob, false)
+		c.RunJobs[name] = newJob
+	}
+
+	// -- Refresh ServiceJobs --
+
+	// Calculate the delta
+	for name, j := range c.ServiceJobs {
+		// this prevents deletion of jobs that were added by reading a configuration file
+		if !j.FromDockerLabel {
+			continue
+		}
+
+		newJob, found := parsedLabelConfig.ServiceJobs[name]
+		if !found {
+			// Remove the job
+			c.sh.RemoveJob(j)
+			delete(c.ServiceJobs, name)
+			continue
+		}
+
+		// For the hash to work properly, we must fill the fields before calling it
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		if configHash(newJob) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		newJob.Client = client
+		newJob.buildMiddlewares()
+		c.replaceJob(j, newJob, true)
+		c.ServiceJobs[name] = newJob
+	}
+
+	// Check for aditions, the jobs of the configuration file are kept
+	for name, newJob := range parsedLabelConfig.ServiceJobs {
+		if _, found := c.ServiceJobs[name]; found {
+			continue
+		}
+
+		defaults.SetDefaults(newJob)
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		newJob.Client = client
+		newJob.Name = name
+		newJob.buildMiddlewares()
+		c.replaceJob(nil, newJob, false)
+		c.ServiceJobs[name] = newJob
 	}
 
 	// -- Refresh LocalJobs --
@@ -300,137 +567,98 @@ func (c *Config) dockerLabelsUpdate(labels map[string]map[string]string) {
 			continue
 		}
 
-		found := false
-		for newJobsName, newJob := range parsedLabelConfig.LocalJobs {
-			// Check if the schedule has changed
-			if name == newJobsName {
-				found = true
-				// There is a slight race condition were a job can be canceled / restarted with different params
-				// so, lets take care of it by simply restarting
-				// For the hash to work properly, we must fill the fields before calling it
-				defaults.SetDefaults(newJob)
-				newJob.Name = newJobsName
-				if newJob.Hash() != j.Hash() {
-					// Remove from the scheduler
-					c.sh.RemoveJob(j)
-					// Add the job back to the scheduler
-					newJob.buildMiddlewares()
-					c.sh.AddJob(newJob)
-					// Update the job config
-					c.LocalJobs[name] = newJob
-				}
-				break
-			}
-		}
+		newJob, found := parsedLabelConfig.LocalJobs[name]
 		if !found {
 			// Remove the job
 			c.sh.RemoveJob(j)
 			delete(c.LocalJobs, name)
+			continue
 		}
-	}
 
-	// Check for aditions
-	for newJobsName, newJob := range parsedLabelConfig.LocalJobs {
-		found := false
-		for name := range c.LocalJobs {
-			if name == newJobsName {
-				found = true
-				break
-			}
+		// For the hash to work properly, we must fill the fields before calling it
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		if configHash(newJob) == configHash(j) {
+			continue
 		}
-		if !found {
-			defaults.SetDefaults(newJob)
-			newJob.Name = newJobsName
-			newJob.buildMiddlewares()
-			c.sh.AddJob(newJob)
-			c.LocalJobs[newJobsName] = newJob
+
+		newJob.buildMiddlewares()
+		c.replaceJob(j, newJob, true)
+		c.LocalJobs[name] = newJob
+	}
+
+	// Check for aditions, the jobs of the configuration file are kept
+	for name, newJob := range parsedLabelConfig.LocalJobs {
+		if _, found := c.LocalJobs[name]; found {
+			continue
 		}
+
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		newJob.buildMiddlewares()
+		c.replaceJob(nil, newJob, false)
+		c.LocalJobs[name] = newJob
 	}
 
 	// -- Refresh LifecycleJobs --
 
-	// Calculate the delta
+	// Calculate the delta, the lifecycle jobs are not scheduled, they are run
+	// by the Docker handler
 	for name, j := range c.LifecycleJobs {
 		// this prevents deletion of jobs that were added by reading a configuration file
 		if !j.FromDockerLabel {
 			continue
 		}
 
-		found := false
-		for newJobsName, newJob := range parsedLabelConfig.LifecycleJobs {
-			// Check if the schedule has changed
-			if name == newJobsName {
-				found = true
-				// For the hash to work properly, we must fill the fields before calling it
-				defaults.SetDefaults(newJob)
-
-				// Set the Docker client based on which handler we're using
-				if c.useOfficialDocker {
-					// We can't use the official client for LifecycleJobs yet
-					// This will need to be updated in a future PR
-					var err error
-					c.dockerHandler, err = NewDockerHandler(c, c.logger)
-					if err != nil {
-						c.logger.Errorf("Failed to create Docker handler: %v", err)
-						continue
-					}
-					newJob.Client = c.dockerHandler.GetInternalDockerClient()
-				} else {
-					newJob.Client = c.dockerHandler.GetInternalDockerClient()
-				}
-
-				newJob.Name = newJobsName
-				if newJob.Hash() != j.Hash() {
-					// Update the job config
-					newJob.buildMiddlewares()
-					c.LifecycleJobs[name] = newJob
-				}
-				break
-			}
-		}
+		newJob, found := parsedLabelConfig.LifecycleJobs[name]
 		if !found {
 			// Remove the job
 			delete(c.LifecycleJobs, name)
+			continue
 		}
+
+		// For the hash to work properly, we must fill the fields before calling it
+		defaults.SetDefaults(newJob)
+		newJob.Name = name
+		if configHash(newJob) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		newJob.Client = client
+		newJob.buildMiddlewares()
+		c.LifecycleJobs[name] = newJob
 	}
 
-	// Check for aditions
-	for newJobsName, newJob := range parsedLabelConfig.LifecycleJobs {
-		found := false
-		for name := range c.LifecycleJobs {
-			if name == newJobsName {
-				found = true
-				break
-			}
+	// Check for aditions, the jobs of the configuration file are kept
+	for name, newJob := range parsedLabelConfig.LifecycleJobs {
+		if _, found := c.LifecycleJobs[name]; found {
+			continue
 		}
-		if !found {
-			defaults.SetDefaults(newJob)
-
-			// Set the Docker client based on which handler we're using
-			if c.useOfficialDocker {
-				// We can't use the official client for LifecycleJobs yet
-				// This will need to be updated in a future PR
-				var err error
-				c.dockerHandler, err = NewDockerHandler(c, c.logger)
-				if err != nil {
-					c.logger.Errorf("Failed to create Docker handler: %v", err)
-					continue
-				}
-				newJob.Client = c.dockerHandler.GetInternalDockerClient()
-			} else {
-				newJob.Client = c.dockerHandler.GetInternalDockerClient()
-			}
 
-			newJob.Name = newJobsName
-			newJob.buildMiddlewares()
-			c.LifecycleJobs[newJobsName] = newJob
+		defaults.SetDefaults(newJob)
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
 		}
+
+		newJob.Client = client
+		newJob.Name = name
+		newJob.buildMiddlewares()
+		c.LifecycleJobs[name] = newJob
 	}
 
 	// Update the lifecycle jobs in the DockerHandler
 	if c.useOfficialDocker {
 		c.officialDockerHandler.SetLifecycleJobs(c.LifecycleJobs)
-	} else {
+	} else if c.dockerHandler != nil {
 		c.dockerHandler.SetLifecycleJobs(c.LifecycleJobs)
 	}
 }
@@ -439,93 +667,170 @@ func (c *Config) dockerLabelsUpdate(labels map[string]map[string]string) {
 type ExecJobConfig struct {
 	core.ExecJob              `mapstructure:",squash"`
 	middlewares.OverlapConfig `mapstructure:",squash"`
+	middlewares.RetryConfig   `mapstructure:",squash"`
+	middlewares.NotifyConfig  `mapstructure:",squash"`
+	middlewares.PingConfig    `mapstructure:",squash"`
 	middlewares.SlackConfig   `mapstructure:",squash"`
 	middlewares.SaveConfig    `mapstructure:",squash"`
 	middlewares.MailConfig    `mapstructure:",squash"`
 	middlewares.GotifyConfig  `mapstructure:",squash"`
+	middlewares.TeamsConfig   `mapstructure:",squash"`
+	middlewares.WebhookConfig `mapstructure:",squash"`
 	FromDockerLabel           bool `mapstructure:"fromDockerLabel"`
 }
 
 func (c *ExecJobConfig) buildMiddlewares() {
+	c.ExecJob.Use(middlewares.NewNotify(&c.NotifyConfig))
 	c.ExecJob.Use(middlewares.NewOverlap(&c.OverlapConfig))
+	c.ExecJob.Use(middlewares.NewRetry(&c.RetryConfig))
+	c.ExecJob.Use(middlewares.NewPing(&c.PingConfig))
 	c.ExecJob.Use(middlewares.NewSlack(&c.SlackConfig))
 	c.ExecJob.Use(middlewares.NewSave(&c.SaveConfig))
 	c.ExecJob.Use(middlewares.NewMail(&c.MailConfig))
 	c.ExecJob.Use(middlewares.NewGotify(&c.GotifyConfig))
+	c.ExecJob.Use(middlewares.NewTeams(&c.TeamsConfig))
+	c.ExecJob.Use(middlewares.NewWebhook(&c.WebhookConfig))
+}
+
+// GetType returns the configuration section name of the job
+func (c *ExecJobConfig) GetType() string {
+	return jobExec
 }
 
 // RunServiceConfig contains all configuration params needed to build a RunJob
 type RunServiceConfig struct {
 	core.RunServiceJob        `mapstructure:",squash"`
 	middlewares.OverlapConfig `mapstructure:",squash"`
+	middlewares.RetryConfig   `mapstructure:",squash"`
+	middlewares.NotifyConfig  `mapstructure:",squash"`
+	middlewares.PingConfig    `mapstructure:",squash"`
 	middlewares.SlackConfig   `mapstructure:",squash"`
 	middlewares.SaveConfig    `mapstructure:",squash"`
 	middlewares.MailConfig    `mapstructure:",squash"`
 	middlewares.GotifyConfig  `mapstructure:",squash"`
+	middlewares.TeamsConfig   `mapstructure:",squash"`
+	middlewares.WebhookConfig `mapstructure:",squash"`
+	FromDockerLabel           bool `mapstructure:"fromDockerLabel" default:"false"`
 }
 
 type RunJobConfig struct {
 	core.RunJob               `mapstructure:",squash"`
 	middlewares.OverlapConfig `mapstructure:",squash"`
+	middlewares.RetryConfig   `mapstructure:",squash"`
+	middlewares.NotifyConfig  `mapstructure:",squash"`
+	middlewares.PingConfig    `mapstructure:",squash"`
 	middlewares.SlackConfig   `mapstructure:",squash"`
 	middlewares.SaveConfig    `mapstructure:",squash"`
 	middlewares.MailConfig    `mapstructure:",squash"`
 	middlewares.GotifyConfig  `mapstructure:",squash"`
+	middlewares.TeamsConfig   `mapstructure:",squash"`
+	middlewares.WebhookConfig `mapstructure:",squash"`
+	FromDockerLabel           bool `mapstructure:"fromDockerLabel" default:"false"`
 
 	// Added for backward compatibility with tests
 	Pull string `default:"true"`
 }
 
 func (c *RunJobConfig) buildMiddlewares() {
+	c.RunJob.Use(middlewares.NewNotify(&c.NotifyConfig))
 	c.RunJob.Use(middlewares.NewOverlap(&c.OverlapConfig))
+	c.RunJob.Use(middlewares.NewRetry(&c.RetryConfig))
+	c.RunJob.Use(middlewares.NewPing(&c.PingConfig))
 	c.RunJob.Use(middlewares.NewSlack(&c.SlackConfig))
 	c.RunJob.Use(middlewares.NewSave(&c.SaveConfig))
 	c.RunJob.Use(middlewares.NewMail(&c.MailConfig))
 	c.RunJob.Use(middlewares.NewGotify(&c.GotifyConfig))
+	c.RunJob.Use(middlewares.NewTeams(&c.TeamsConfig))
+	c.RunJob.Use(middlewares.NewWebhook(&c.WebhookConfig))
+}
+
+// GetType returns the configuration section name of the job
+func (c *RunJobConfig) GetType() string {
+	return jobRun
 }
 
 // LocalJobConfig contains all configuration params needed to build a RunJob
 type LocalJobConfig struct {
 	core.LocalJob             `mapstructure:",squash"`
 	middlewares.OverlapConfig `mapstructure:",squash"`
+	middlewares.RetryConfig   `mapstructure:",squash"`
+	middlewares.NotifyConfig  `mapstructure:",squash"`
+	middlewares.PingConfig    `mapstructure:",squash"`
 	middlewares.SlackConfig   `mapstructure:",squash"`
 	middlewares.SaveConfig    `mapstructure:",squash"`
 	middlewares.MailConfig    `mapstructure:",squash"`
 	middlewares.GotifyConfig  `mapstructure:",squash"`
+	middlewares.TeamsConfig   `mapstructure:",squash"`
+	middlewares.WebhookConfig `mapstructure:",squash"`
 	FromDockerLabel           bool `mapstructure:"fromDockerLabel" default:"false"`
 }
 
 func (c *LocalJobConfig) buildMiddlewares() {
+	c.LocalJob.Use(middlewares.NewNotify(&c.NotifyConfig))
 	c.LocalJob.Use(middlewares.NewOverlap(&c.OverlapConfig))
+	c.LocalJob.Use(middlewares.NewRetry(&c.RetryConfig))
+	c.LocalJob.Use(middlewares.NewPing(&c.PingConfig))
 	c.LocalJob.Use(middlewares.NewSlack(&c.SlackConfig))
 	c.LocalJob.Use(middlewares.NewSave(&c.SaveConfig))
 	c.LocalJob.Use(middlewares.NewMail(&c.MailConfig))
 	c.LocalJob.Use(middlewares.NewGotify(&c.GotifyConfig))
+	c.LocalJob.Use(middlewares.NewTeams(&c.TeamsConfig))
+	c.LocalJob.Use(middlewares.NewWebhook(&c.WebhookConfig))
+}
+
+// GetType returns the configuration section name of the job
+func (c *LocalJobConfig) GetType() string {
+	return jobLocal
 }
 
 func (c *RunServiceConfig) buildMiddlewares() {
+	c.RunServiceJob.Use(middlewares.NewNotify(&c.NotifyConfig))
 	c.RunServiceJob.Use(middlewares.NewOverlap(&c.OverlapConfig))
+	c.RunServiceJob.Use(middlewares.NewRetry(&c.RetryConfig))
+	c.RunServiceJob.Use(middlewares.NewPing(&c.PingConfig))
 	c.RunServiceJob.Use(middlewares.NewSlack(&c.SlackConfig))
 	c.RunServiceJob.Use(middlewares.NewSave(&c.SaveConfig))
 	c.RunServiceJob.Use(middlewares.NewMail(&c.MailConfig))
 	c.RunServiceJob.Use(middlewares.NewGotify(&c.GotifyConfig))
+	c.RunServiceJob.Use(middlewares.NewTeams(&c.TeamsConfig))
+	c.RunServiceJob.Use(middlewares.NewWebhook(&c.WebhookConfig))
+}
+
+// GetType returns the configuration section name of the job
+func (c *RunServiceConfig) GetType() string {
+	return jobServiceRun
 }
 
 // LifecycleJobConfig contains all configuration params needed to build a LifecycleJob
 type LifecycleJobConfig struct {
 	core.LifecycleJob         `mapstructure:",squash"`
 	middlewares.OverlapConfig `mapstructure:",squash"`
+	middlewares.RetryConfig   `mapstructure:",squash"`
+	middlewares.NotifyConfig  `mapstructure:",squash"`
+	middlewares.PingConfig    `mapstructure:",squash"`
 	middlewares.SlackConfig   `mapstructure:",squash"`
 	middlewares.SaveConfig    `mapstructure:",squash"`
 	middlewares.MailConfig    `mapstructure:",squash"`
 	middlewares.GotifyConfig  `mapstructure:",squash"`
+	middlewares.TeamsConfig   `mapstructure:",squash"`
+	middlewares.WebhookConfig `mapstructure:",squash"`
 	FromDockerLabel           bool `mapstructure:"fromDockerLabel" default:"false"`
 }
 
 func (c *LifecycleJobConfig) buildMiddlewares() {
+	c.LifecycleJob.Use(middlewares.NewNotify(&c.NotifyConfig))
 	c.LifecycleJob.Use(middlewares.NewOverlap(&c.OverlapConfig))
+	c.LifecycleJob.Use(middlewares.NewRetry(&c.RetryConfig))
+	c.LifecycleJob.Use(middlewares.NewPing(&c.PingConfig))
 	c.LifecycleJob.Use(middlewares.NewSlack(&c.SlackConfig))
 	c.LifecycleJob.Use(middlewares.NewSave(&c.SaveConfig))
 	c.LifecycleJob.Use(middlewares.NewMail(&c.MailConfig))
 	c.LifecycleJob.Use(middlewares.NewGotify(&c.GotifyConfig))
+	c.LifecycleJob.Use(middlewares.NewTeams(&c.TeamsConfig))
+	c.LifecycleJob.Use(middlewares.NewWebhook(&c.WebhookConfig))
+}
+
+// GetType returns the configuration section name of the job
+func (c *LifecycleJobConfig) GetType() string {
+	return jobLifecycle
 }
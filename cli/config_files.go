@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	gcfg "gopkg.in/gcfg.v1"
+	warnings "gopkg.in/warnings.v0"
+)
+
+// configExtensions are the extensions of the files read from a configuration
+// directory
+var configExtensions = map[string]bool{
+	".conf": true,
+	".ini":  true,
+	".yaml": true,
+	".yml":  true,
+	".toml": true,
+}
+
+// fileError is an error of a file read from a directory or an include, the
+// errors of the main configuration file are returned as is
+type fileError struct {
+	file string
+	err  error
+}
+
+func (e *fileError) Error() string {
+	return e.file + ": " + e.err.Error()
+}
+
+func (e *fileError) Unwrap() error {
+	return e.err
+}
+
+// configLoader reads the configuration files and merges them into a Config
+type configLoader struct {
+	config   *Config
+	root     string
+	read     map[string]bool
+	globals  map[string]string
+	webhooks map[string]string
+	warnings []error
+}
+
+func newConfigLoader(root string, logger core.Logger) *configLoader {
+	c := NewConfig(logger)
+	c.paths = []string{root}
+
+	return &configLoader{
+		config:   c,
+		root:     filepath.Clean(root),
+		read:     make(map[string]bool),
+		globals:  make(map[string]string),
+		webhooks: make(map[string]string),
+	}
+}
+
+// loadPath reads a configuration file, or the configuration files of a
+// directory sorted by name
+func (l *configLoader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return l.fileError(path, err)
+	}
+
+	if !info.IsDir() {
+		return l.loadFile(path)
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return l.fileError(path, err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !configExtensions[strings.ToLower(filepath.Ext(f.Name()))] {
+			continue
+		}
+
+		if err := l.loadFile(filepath.Join(path, f.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *configLoader) loadFile(file string) error {
+	file = filepath.Clean(file)
+	if l.read[file] {
+		return nil
+	}
+
+	l.read[file] = true
+	l.config.logger.Debugf("Reading configuration file %q", file)
+
+	c, err := readConfigFile(file, l.config.logger)
+	if err != nil {
+		if fatal := gcfg.FatalOnly(err); fatal != nil {
+			return l.fileError(file, fatal)
+		}
+
+		for _, w := range warnings.WarningsOnly(err) {
+			l.warnings = append(l.warnings, l.fileError(file, w))
+		}
+	}
+
+	if err := l.merge(c, file); err != nil {
+		return err
+	}
+
+	for _, pattern := range c.Global.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(file), pattern)
+		}
+
+		l.config.paths = append(l.config.paths, pattern)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return l.fileError(file, fmt.Errorf("invalid include %q: %s", pattern, err))
+		}
+
+		// a pattern may match no file, eg. an empty directory
+		if len(matches) == 0 && !strings.ContainsAny(pattern, `*?[\`) {
+			return l.fileError(file, fmt.Errorf("include %q: no such file or directory", pattern))
+		}
+
+		for _, m := range matches {
+			if err := l.loadPath(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *configLoader) fileError(file string, err error) error {
+	if filepath.Clean(file) == l.root {
+		return err
+	}
+
+	return &fileError{file: file, err: err}
+}
+
+// merge adds the global options, the webhooks and the jobs of a file to the
+// configuration, a job or a webhook cannot be defined in two files and a
+// global option cannot be set to different values
+func (l *configLoader) merge(c *Config, file string) error {
+	err := l.mergeGlobal(reflect.ValueOf(&l.config.Global).Elem(), reflect.ValueOf(&c.Global).Elem(), file)
+	if err != nil {
+		return err
+	}
+
+	for name, w := range c.Webhooks {
+		if prev, ok := l.webhooks[name]; ok && prev != file {
+			return fmt.Errorf("webhook %q defined in both %s and %s", name, prev, file)
+		}
+
+		l.webhooks[name] = file
+		l.config.Webhooks[name] = w
+	}
+
+	for name, j := range c.ExecJobs {
+		if err := l.addSource(name, file); err != nil {
+			return err
+		}
+
+		l.config.ExecJobs[name] = j
+	}
+
+	for name, j := range c.RunJobs {
+		if err := l.addSource(name, file); err != nil {
+			return err
+		}
+
+		l.config.RunJobs[name] = j
+	}
+
+	for name, j := range c.ServiceJobs {
+		if err := l.addSource(name, file); err != nil {
+			return err
+		}
+
+		l.config.ServiceJobs[name] = j
+	}
+
+	for name, j := range c.LocalJobs {
+		if err := l.addSource(name, file); err != nil {
+			return err
+		}
+
+		l.config.LocalJobs[name] = j
+	}
+
+	for name, j := range c.LifecycleJobs {
+		if err := l.addSource(name, file); err != nil {
+			return err
+		}
+
+		l.config.LifecycleJobs[name] = j
+	}
+
+	return nil
+}
+
+func (l *configLoader) mergeGlobal(dst, src reflect.Value, file string) error {
+	t := src.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := l.mergeGlobal(dst.Field(i), src.Field(i), file); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if f.PkgPath != "" || f.Name == "Include" || src.Field(i).IsZero() {
+			continue
+		}
+
+		name := optionName(f)
+		if prev, ok := l.globals[name]; ok {
+			if !reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()) {
+				return fmt.Errorf("global option %q set to different values in %s and %s", name, prev, file)
+			}
+
+			continue
+		}
+
+		dst.Field(i).Set(src.Field(i))
+		l.globals[name] = file
+	}
+
+	return nil
+}
+
+func (l *configLoader) addSource(name, file string) error {
+	if prev, ok := l.config.sources[name]; ok && prev != file {
+		return fmt.Errorf("job %q defined in both %s and %s", name, prev, file)
+	}
+
+	l.config.sources[name] = file
+	return nil
+}
+
+// jobSource returns the file a job is defined in, empty for the jobs of the
+// Docker labels
+func (c *Config) jobSource(name string) string {
+	return c.sources[name]
+}
+
+// describeJob returns the name of a job with the file it is defined in, for
+// the messages
+func (c *Config) describeJob(name string) string {
+	if source := c.jobSource(name); source != "" {
+		return fmt.Sprintf("%q (%s)", name, source)
+	}
+
+	return fmt.Sprintf("%q", name)
+}
+
+// watchedPaths returns the files, directories and glob patterns the
+// configuration was read from
+func (c *Config) watchedPaths() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string(nil), c.paths...)
+}
+
+// configVersion identifies a version of the configuration files by their
+// names, sizes and modification times
+func configVersion(paths []string) string {
+	var version []string
+	for _, pattern := range paths {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			version = append(version, pathVersion(path)...)
+		}
+	}
+
+	return strings.Join(version, ";")
+}
+
+func pathVersion(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	if !info.IsDir() {
+		return []string{fmt.Sprintf("%s-%d-%d", path, info.ModTime().UnixNano(), info.Size())}
+	}
+
+	files, _ := ioutil.ReadDir(path)
+
+	var version []string
+	for _, f := range files {
+		if !f.IsDir() && configExtensions[strings.ToLower(filepath.Ext(f.Name()))] {
+			version = append(version, fmt.Sprintf("%s-%d-%d", f.Name(), f.ModTime().UnixNano(), f.Size()))
+		}
+	}
+
+	return version
+}
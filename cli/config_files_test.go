@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	warnings "gopkg.in/warnings.v0"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteConfigFiles struct {
+	dir string
+}
+
+var _ = Suite(&SuiteConfigFiles{})
+
+func (s *SuiteConfigFiles) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+}
+
+func (s *SuiteConfigFiles) write(c *C, name, content string) string {
+	filename := filepath.Join(s.dir, name)
+	c.Assert(os.MkdirAll(filepath.Dir(filename), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+	return filename
+}
+
+func (s *SuiteConfigFiles) TestDirectory(c *C) {
+	s.write(c, "global.conf", `
+		[global]
+		timezone = UTC
+	`)
+	backup := s.write(c, "backup.ini", `
+		[job-local "backup"]
+		schedule = @daily
+		command = true
+	`)
+	dump := s.write(c, "dump.yml", `
+job-exec:
+  dump:
+    schedule: "@hourly"
+    container: db
+    command: pg_dump
+`)
+	s.write(c, "README.md", "not a configuration file")
+
+	config, err := BuildFromFile(s.dir, &TestLogger{})
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.Timezone, Equals, "UTC")
+	c.Assert(config.LocalJobs, HasLen, 1)
+	c.Assert(config.ExecJobs, HasLen, 1)
+	c.Assert(config.jobSource("backup"), Equals, backup)
+	c.Assert(config.jobSource("dump"), Equals, dump)
+	c.Assert(config.describeJob("dump"), Equals, `"dump" (`+dump+`)`)
+}
+
+func (s *SuiteConfigFiles) TestInclude(c *C) {
+	root := s.write(c, "chadburn.conf", `
+		[global]
+		include = conf.d/*.conf
+		include = extra.toml
+
+		[job-local "backup"]
+		schedule = @daily
+		command = true
+	`)
+	report := s.write(c, "conf.d/report.conf", `
+		[job-local "report"]
+		depends-on = backup
+		command = true
+	`)
+	s.write(c, "extra.toml", `
+[job-run.cleanup]
+schedule = "@weekly"
+image = "busybox"
+`)
+
+	config, err := BuildFromFile(root, &TestLogger{})
+	c.Assert(err, IsNil)
+	c.Assert(config.LocalJobs, HasLen, 2)
+	c.Assert(config.RunJobs, HasLen, 1)
+	c.Assert(config.jobSource("backup"), Equals, root)
+	c.Assert(config.jobSource("report"), Equals, report)
+	c.Assert(config.watchedPaths(), DeepEquals, []string{
+		root, filepath.Join(s.dir, "conf.d/*.conf"), filepath.Join(s.dir, "extra.toml"),
+	})
+}
+
+func (s *SuiteConfigFiles) TestIncludeMissing(c *C) {
+	root := s.write(c, "chadburn.conf", `
+		[global]
+		include = conf.d/*.conf
+	`)
+
+	_, err := BuildFromFile(root, &TestLogger{})
+	c.Assert(err, IsNil)
+
+	s.write(c, "chadburn.conf", `
+		[global]
+		include = missing.conf
+	`)
+
+	_, err = BuildFromFile(root, &TestLogger{})
+	c.Assert(err, ErrorMatches, `include ".*missing.conf": no such file or directory`)
+}
+
+func (s *SuiteConfigFiles) TestDuplicateJob(c *C) {
+	a := s.write(c, "a.conf", `
+		[job-local "backup"]
+		schedule = @daily
+		command = true
+	`)
+	b := s.write(c, "b.conf", `
+		[job-exec "backup"]
+		schedule = @daily
+		container = db
+		command = true
+	`)
+
+	_, err := BuildFromFile(s.dir, &TestLogger{})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, `job "backup" defined in both `+a+` and `+b)
+}
+
+func (s *SuiteConfigFiles) TestDuplicateWebhook(c *C) {
+	a := s.write(c, "a.conf", `
+		[webhook "chat"]
+		webhook-url = https://chat.example.com/a
+	`)
+	b := s.write(c, "b.conf", `
+		[webhook "chat"]
+		webhook-url = https://chat.example.com/b
+	`)
+
+	_, err := BuildFromFile(s.dir, &TestLogger{})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, `webhook "chat" defined in both `+a+` and `+b)
+}
+
+func (s *SuiteConfigFiles) TestGlobalConflict(c *C) {
+	s.write(c, "a.conf", `
+		[global]
+		timezone = UTC
+		slack-webhook = https://example.com/hook
+	`)
+	s.write(c, "b.conf", `
+		[global]
+		timezone = UTC
+	`)
+
+	config, err := BuildFromFile(s.dir, &TestLogger{})
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.SlackWebhook, Equals, "https://example.com/hook")
+
+	s.write(c, "c.conf", `
+		[global]
+		timezone = Europe/Madrid
+	`)
+
+	_, err = BuildFromFile(s.dir, &TestLogger{})
+	c.Assert(err, ErrorMatches, `global option "timezone" set to different values in .*a.conf and .*c.conf`)
+}
+
+func (s *SuiteConfigFiles) TestFileErrors(c *C) {
+	root := s.write(c, "chadburn.conf", `
+		[global]
+		include = conf.d/*.conf
+
+		[job-local "backup"]
+		schedule = @daily
+		command = true
+		comand = true
+	`)
+	included := s.write(c, "conf.d/report.conf", `
+		[job-local "report"]
+		schedule = @daily
+		command = true
+		foo = bar
+	`)
+
+	_, err := BuildFromFile(root, &TestLogger{})
+	list, ok := err.(warnings.List)
+	c.Assert(ok, Equals, true)
+	c.Assert(list.Warnings, HasLen, 2)
+	c.Assert(list.Warnings[0], Not(FitsTypeOf), &fileError{})
+	c.Assert(list.Warnings[1], FitsTypeOf, &fileError{})
+	c.Assert(list.Warnings[1].(*fileError).file, Equals, included)
+
+	s.write(c, "conf.d/report.conf", `[job-local "report"`)
+	_, err = BuildFromFile(root, &TestLogger{})
+	c.Assert(err, FitsTypeOf, &fileError{})
+	c.Assert(err.(*fileError).file, Equals, included)
+}
@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/mapstructure"
+	warnings "gopkg.in/warnings.v0"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the configuration file
+const (
+	formatINI  = "ini"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// configFormat returns the format of a configuration file from its
+// extension, INI for any extension but .yaml, .yml and .toml
+func configFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	}
+
+	return formatINI
+}
+
+// decode reads a YAML or TOML configuration, the sections and keys are the
+// ones of the INI files. The unknown sections and keys are returned as
+// warnings, as gcfg does.
+func (c *Config) decode(format string, src []byte) error {
+	var doc map[string]interface{}
+	var err error
+	switch format {
+	case formatYAML:
+		err = yaml.Unmarshal(src, &doc)
+	case formatTOML:
+		err = toml.Unmarshal(src, &doc)
+	default:
+		return fmt.Errorf("unknown configuration format %q", format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", format, err)
+	}
+
+	var unknown []error
+	for section, value := range doc {
+		var target interface{}
+		switch section {
+		case "global":
+			target = &c.Global
+		case jobExec:
+			target = &c.ExecJobs
+		case jobRun:
+			target = &c.RunJobs
+		case jobServiceRun:
+			target = &c.ServiceJobs
+		case jobLocal:
+			target = &c.LocalJobs
+		case jobLifecycle:
+			target = &c.LifecycleJobs
+		case webhookSection:
+			target = &c.Webhooks
+		default:
+			unknown = append(unknown, unknownData{section: section})
+			continue
+		}
+
+		// a job without options is decoded as nil
+		if jobs, ok := value.(map[string]interface{}); ok && section != "global" {
+			for name, job := range jobs {
+				if job == nil {
+					jobs[name] = map[string]interface{}{}
+				}
+			}
+		}
+
+		var md mapstructure.Metadata
+		d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			Metadata:         &md,
+			Result:           target,
+			WeaklyTypedInput: true,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := d.Decode(value); err != nil {
+			return fmt.Errorf("invalid section %q: %s", section, err)
+		}
+
+		for _, key := range md.Unused {
+			unknown = append(unknown, newUnknownData(section, key))
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Slice(unknown, func(i, k int) bool {
+		return unknown[i].Error() < unknown[k].Error()
+	})
+
+	return warnings.List{Warnings: unknown}
+}
+
+// unknownData is a section or a key of a YAML or TOML file that is not part
+// of the configuration, described as gcfg does for the INI files
+type unknownData struct {
+	section, subsection, variable string
+}
+
+// newUnknownData returns the unknownData of an unused key reported by
+// mapstructure, as "key" for the global section or "[job].key" for the jobs
+func newUnknownData(section, key string) unknownData {
+	e := unknownData{section: section, variable: key}
+	if strings.HasPrefix(key, "[") {
+		if i := strings.Index(key, "]."); i > 0 {
+			e.subsection, e.variable = key[1:i], key[i+2:]
+		}
+	}
+
+	return e
+}
+
+func (e unknownData) Error() string {
+	s := fmt.Sprintf("can't store data at section %q", e.section)
+	if e.subsection != "" {
+		s += fmt.Sprintf(", subsection %q", e.subsection)
+	}
+
+	if e.variable != "" {
+		s += fmt.Sprintf(", variable %q", e.variable)
+	}
+
+	return s
+}
+
+// configSection is a section of the configuration, with its options by name
+type configSection struct {
+	name    string
+	options map[string]interface{}
+}
+
+// sections returns the sections of the configuration with the options set,
+// the global one first, then the webhooks sorted by name and the jobs sorted
+// by type and name
+func (c *Config) sections() []configSection {
+	var sections []configSection
+	if global := configOptions(reflect.ValueOf(&c.Global).Elem()); len(global) > 0 {
+		sections = append(sections, configSection{"global", global})
+	}
+
+	webhooks := make([]string, 0, len(c.Webhooks))
+	for name := range c.Webhooks {
+		webhooks = append(webhooks, name)
+	}
+
+	sort.Strings(webhooks)
+	for _, name := range webhooks {
+		options := configOptions(reflect.ValueOf(c.Webhooks[name]).Elem())
+		sections = append(sections, configSection{jobSection(webhookSection, name), options})
+	}
+
+	for _, jobs := range []struct {
+		typ  string
+		jobs interface{}
+	}{
+		{jobExec, c.ExecJobs},
+		{jobRun, c.RunJobs},
+		{jobServiceRun, c.ServiceJobs},
+		{jobLocal, c.LocalJobs},
+		{jobLifecycle, c.LifecycleJobs},
+	} {
+		v := reflect.ValueOf(jobs.jobs)
+		names := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			names = append(names, k.String())
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			job := v.MapIndex(reflect.ValueOf(name)).Elem()
+			sections = append(sections, configSection{jobSection(jobs.typ, name), configOptions(job)})
+		}
+	}
+
+	return sections
+}
+
+// configOptions returns the options of a section set to a non zero value,
+// named as in the INI files
+func configOptions(v reflect.Value) map[string]interface{} {
+	options := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Tag.Get("json") == "-" || f.Tag.Get("hash") == "-" {
+			continue
+		}
+
+		// the name of the jobs is the one of the section, and the origin of
+		// the job is not an option
+		if f.Name == "Name" || f.Name == "FromDockerLabel" {
+			continue
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for k, value := range configOptions(v.Field(i)) {
+				options[k] = value
+			}
+
+			continue
+		}
+
+		if v.Field(i).IsZero() {
+			continue
+		}
+
+		options[optionName(f)] = v.Field(i).Interface()
+	}
+
+	return options
+}
+
+// optionName returns the name of the option of a field, as in the INI files
+func optionName(f reflect.StructField) string {
+	if tag := f.Tag.Get("gcfg"); tag != "" {
+		return tag
+	}
+
+	return strings.ToLower(f.Name)
+}
+
+// encode writes the configuration in the given format
+func (c *Config) encode(format string, w io.Writer) error {
+	sections := c.sections()
+	if format == formatINI {
+		return writeINI(sections, w)
+	}
+
+	doc := make(map[string]interface{})
+	for _, s := range sections {
+		if s.name == "global" {
+			doc["global"] = s.options
+			continue
+		}
+
+		typ, name := splitJobSection(s.name)
+		jobs, ok := doc[typ].(map[string]interface{})
+		if !ok {
+			jobs = make(map[string]interface{})
+			doc[typ] = jobs
+		}
+
+		jobs[name] = s.options
+	}
+
+	switch format {
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+
+		return enc.Close()
+	case formatTOML:
+		enc := toml.NewEncoder(w)
+		enc.Indent = ""
+		return enc.Encode(doc)
+	}
+
+	return fmt.Errorf("unknown configuration format %q", format)
+}
+
+// splitJobSection returns the type and the name of the job of a section
+// named as `job-exec "name"`
+func splitJobSection(section string) (string, string) {
+	parts := strings.SplitN(section, " ", 2)
+	name, _ := strconv.Unquote(parts[1])
+	return parts[0], name
+}
+
+func writeINI(sections []configSection, w io.Writer) error {
+	b := bufio.NewWriter(w)
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Fprintln(b)
+		}
+
+		fmt.Fprintf(b, "[%s]\n", s.name)
+
+		keys := make([]string, 0, len(s.options))
+		for k := range s.options {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		for _, k := range keys {
+			values, ok := s.options[k].([]string)
+			if !ok {
+				values = []string{fmt.Sprint(s.options[k])}
+			}
+
+			// the lists are written as a repeated option
+			for _, v := range values {
+				fmt.Fprintf(b, "%s = %s\n", k, quoteINI(v))
+			}
+		}
+	}
+
+	return b.Flush()
+}
+
+// quoteINI quotes a value for gcfg if it would not be read as is otherwise
+func quoteINI(v string) string {
+	if !strings.ContainsAny(v, "\"\\;#\n\t") && strings.TrimSpace(v) == v {
+		return v
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(v) + `"`
+}
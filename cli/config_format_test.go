@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	gcfg "gopkg.in/gcfg.v1"
+	warnings "gopkg.in/warnings.v0"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteConfigFormat struct{}
+
+var _ = Suite(&SuiteConfigFormat{})
+
+const formatTestINI = `
+[global]
+timezone = Europe/Madrid
+slack-webhook = https://hooks.example.com/x
+slack-only-on-error = true
+
+[job-exec "dump"]
+schedule = @daily
+container = db
+command = "sh -c \"pg_dump > /backup/db.sql; echo done\""
+retry-count = 3
+
+[job-run "cleanup"]
+schedule = 0 4 * * *
+image = busybox
+volume = /data:/data
+volume = /tmp:/tmp
+
+[job-local "report"]
+depends-on = cleanup
+command = report --all
+environment = FOO=bar
+
+[job-lifecycle "hello"]
+container = web
+command = echo hi
+eventtype = start
+
+[webhook "pagerduty"]
+webhook-url = https://events.example.com/alert
+webhook-jobs = dump
+`
+
+func (s *SuiteConfigFormat) writeFile(c *C, name, content string) string {
+	filename := filepath.Join(c.MkDir(), name)
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+	return filename
+}
+
+func (s *SuiteConfigFormat) TestBuildFromYAML(c *C) {
+	config, err := BuildFromFile(s.writeFile(c, "chadburn.yml", `
+global:
+  timezone: Europe/Madrid
+  slack-only-on-error: true
+job-run:
+  cleanup:
+    schedule: 0 4 * * *
+    image: busybox
+    volume:
+      - /data:/data
+      - /tmp:/tmp
+    retry-count: "3"
+job-local:
+  empty:
+`), &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.Timezone, Equals, "Europe/Madrid")
+	c.Assert(config.Global.SlackOnlyOnError, Equals, true)
+	c.Assert(config.RunJobs["cleanup"].Image, Equals, "busybox")
+	c.Assert(config.RunJobs["cleanup"].Volume, DeepEquals, []string{"/data:/data", "/tmp:/tmp"})
+	c.Assert(config.RunJobs["cleanup"].RetryCount, Equals, 3)
+	c.Assert(config.LocalJobs["empty"], NotNil)
+}
+
+func (s *SuiteConfigFormat) TestBuildFromTOML(c *C) {
+	config, err := BuildFromFile(s.writeFile(c, "chadburn.toml", `
+[global]
+timezone = "Europe/Madrid"
+
+[job-exec.dump]
+schedule = "@daily"
+container = "db"
+command = "pg_dump"
+tty = true
+`), &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.Timezone, Equals, "Europe/Madrid")
+	c.Assert(config.ExecJobs["dump"].Container, Equals, "db")
+	c.Assert(config.ExecJobs["dump"].TTY, Equals, true)
+}
+
+func (s *SuiteConfigFormat) TestBuildWebhooks(c *C) {
+	config, err := BuildFromFile(s.writeFile(c, "chadburn.ini", `
+[webhook "pagerduty"]
+webhook-url = https://events.example.com/alert
+webhook-only-on-error = true
+webhook-jobs = dump, cleanup
+`), &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Webhooks["pagerduty"].WebhookURL, Equals, "https://events.example.com/alert")
+	c.Assert(config.Webhooks["pagerduty"].WebhookOnlyOnError, Equals, true)
+	c.Assert(config.Webhooks["pagerduty"].Jobs(), DeepEquals, []string{"dump", "cleanup"})
+
+	config, err = BuildFromFile(s.writeFile(c, "chadburn.yaml", `
+webhook:
+  pagerduty:
+    webhook-url: https://events.example.com/alert
+  chat:
+    webhook-url: https://chat.example.com/hook
+`), &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Webhooks, HasLen, 2)
+	c.Assert(config.Webhooks["chat"].WebhookURL, Equals, "https://chat.example.com/hook")
+}
+
+func (s *SuiteConfigFormat) TestBuildUnknownKeys(c *C) {
+	config, err := BuildFromFile(s.writeFile(c, "chadburn.yaml", `
+global:
+  timezon: UTC
+job-local:
+  backup:
+    schedule: "@daily"
+    comand: backup
+jobs: {}
+`), &TestLogger{})
+
+	c.Assert(gcfg.FatalOnly(err), IsNil)
+	c.Assert(config.LocalJobs["backup"].Schedule, Equals, "@daily")
+
+	var messages []string
+	for _, w := range warnings.WarningsOnly(err) {
+		messages = append(messages, w.Error())
+	}
+
+	c.Assert(messages, DeepEquals, []string{
+		`can't store data at section "global", variable "timezon"`,
+		`can't store data at section "job-local", subsection "backup", variable "comand"`,
+		`can't store data at section "jobs"`,
+	})
+}
+
+func (s *SuiteConfigFormat) TestBuildInvalid(c *C) {
+	_, err := BuildFromFile(s.writeFile(c, "chadburn.yaml", "job-local: [foo"), &TestLogger{})
+	c.Assert(err, ErrorMatches, "invalid yaml: .*")
+
+	_, err = BuildFromFile(s.writeFile(c, "chadburn.yaml", "job-local:\n  foo:\n    schedule: [1]\n"), &TestLogger{})
+	c.Assert(err, ErrorMatches, `(?s)invalid section "job-local": .*`)
+}
+
+func (s *SuiteConfigFormat) TestConvert(c *C) {
+	expected, err := BuildFromFile(s.writeFile(c, "chadburn.ini", formatTestINI), &TestLogger{})
+	c.Assert(err, IsNil)
+
+	file := s.writeFile(c, "chadburn.ini", formatTestINI)
+	for _, format := range []string{formatYAML, formatTOML, formatINI} {
+		b := bytes.NewBuffer(nil)
+		cmd := &ConvertCommand{To: format, Logger: &TestLogger{}, output: b}
+		cmd.Args.File = file
+		c.Assert(cmd.Execute(nil), IsNil)
+
+		file = s.writeFile(c, "chadburn."+format, b.String())
+		config, err := BuildFromFile(file, &TestLogger{})
+		c.Assert(err, IsNil)
+		c.Assert(config.Global, DeepEquals, expected.Global)
+		c.Assert(config.ExecJobs, DeepEquals, expected.ExecJobs)
+		c.Assert(config.RunJobs, DeepEquals, expected.RunJobs)
+		c.Assert(config.LocalJobs, DeepEquals, expected.LocalJobs)
+		c.Assert(config.LifecycleJobs, DeepEquals, expected.LifecycleJobs)
+		c.Assert(config.Webhooks, DeepEquals, expected.Webhooks)
+	}
+}
+
+func (s *SuiteConfigFormat) TestConvertINI(c *C) {
+	config, err := BuildFromFile(s.writeFile(c, "chadburn.yaml", `
+job-run:
+  cleanup:
+    schedule: "@daily"
+    image: busybox
+    command: sh -c "rm -rf /tmp/*; echo done"
+    volume: [/data:/data, /tmp:/tmp]
+`), &TestLogger{})
+	c.Assert(err, IsNil)
+
+	b := bytes.NewBuffer(nil)
+	c.Assert(config.encode(formatINI, b), IsNil)
+	c.Assert(b.String(), Equals, `[job-run "cleanup"]
+command = "sh -c \"rm -rf /tmp/*; echo done\""
+image = busybox
+schedule = @daily
+volume = /data:/data
+volume = /tmp:/tmp
+`)
+}
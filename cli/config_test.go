@@ -45,6 +45,154 @@ func (s *SuiteConfig) TestBuildFromString(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *SuiteConfig) TestCheckDependencies(c *C) {
+	config, err := BuildFromString(`
+		[job-local "dump"]
+		schedule = @daily
+		on-success = compress
+
+		[job-local "compress"]
+		schedule = @triggered
+
+		[job-local "upload"]
+		depends-on = compress
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.CheckDependencies(), IsNil)
+	c.Assert(config.LocalJobs["upload"].GetDependencies().DependsOn, DeepEquals, []string{"compress"})
+}
+
+func (s *SuiteConfig) TestCheckDependenciesCycle(c *C) {
+	config, err := BuildFromString(`
+		[job-local "dump"]
+		schedule = @daily
+		depends-on = upload
+
+		[job-exec "upload"]
+		depends-on = dump
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.CheckDependencies(), ErrorMatches, ".*cycle.*")
+	c.Assert(config.InitializeApp(true), ErrorMatches, ".*cycle.*")
+}
+
+func (s *SuiteConfig) TestSchedulerOptions(c *C) {
+	config, err := BuildFromString(`
+		[global]
+		timezone = Europe/Madrid
+		enable-seconds = true
+
+		[job-local "tick"]
+		schedule = */10 * * * * *
+		command = true
+
+		[job-local "report"]
+		schedule = 0 9 * * 1
+		timezone = America/New_York
+		command = true
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.InitializeApp(true), IsNil)
+	c.Assert(config.sh.GetJobs(), HasLen, 2)
+	c.Assert(config.LocalJobs["report"].GetTimezone(), Equals, "America/New_York")
+}
+
+func (s *SuiteConfig) TestSchedulerOptionsInvalidTimezone(c *C) {
+	config, err := BuildFromString(`
+		[global]
+		timezone = Nowhere/Special
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.InitializeApp(true), ErrorMatches, `invalid timezone "Nowhere/Special".*`)
+}
+
+func (s *SuiteConfig) TestTeamsConfig(c *C) {
+	config, err := BuildFromString(`
+		[global]
+		teams-webhook = https://example.com/global
+
+		[job-local "report"]
+		schedule = @daily
+		command = true
+		teams-webhook = https://example.com/report
+		teams-only-on-error = true
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.InitializeApp(true), IsNil)
+	c.Assert(config.Global.TeamsWebhook, Equals, "https://example.com/global")
+
+	job := config.LocalJobs["report"]
+	c.Assert(job.TeamsConfig, DeepEquals, middlewares.TeamsConfig{
+		TeamsWebhook:     "https://example.com/report",
+		TeamsOnlyOnError: true,
+	})
+
+	var found bool
+	for _, m := range job.Middlewares() {
+		if _, ok := m.(*middlewares.Teams); ok {
+			found = true
+		}
+	}
+
+	c.Assert(found, Equals, true)
+}
+
+func (s *SuiteConfig) TestTeamsLabels(c *C) {
+	conf := NewConfig(&TestLogger{})
+	err := conf.buildFromDockerLabels(map[string]map[string]string{
+		"some": {
+			requiredLabel:                  "true",
+			serviceLabel:                   "true",
+			labelPrefix + ".teams-webhook": "https://example.com/global",
+			labelPrefix + "." + jobLocal + ".job1.schedule":            "@daily",
+			labelPrefix + "." + jobLocal + ".job1.teams-webhook":       "https://example.com/job1",
+			labelPrefix + "." + jobLocal + ".job1.teams-only-on-error": "true",
+		},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(conf.Global.TeamsWebhook, Equals, "https://example.com/global")
+	c.Assert(conf.LocalJobs["job1"].TeamsWebhook, Equals, "https://example.com/job1")
+	c.Assert(conf.LocalJobs["job1"].TeamsOnlyOnError, Equals, true)
+}
+
+func (s *SuiteConfig) TestWebhookConfig(c *C) {
+	config, err := BuildFromString(`
+		[job-local "report"]
+		schedule = @daily
+		command = true
+		webhook-url = https://example.com/hook
+		webhook-header = Authorization: Bearer foo
+		webhook-header = X-Team: ops
+		webhook-template = "{\"text\": {{json .Job.GetName}}}"
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+
+	job := config.LocalJobs["report"]
+	c.Assert(job.WebhookHeaders, DeepEquals, []string{"Authorization: Bearer foo", "X-Team: ops"})
+	c.Assert(job.WebhookTemplate, Equals, `{"text": {{json .Job.GetName}}}`)
+
+	conf := NewConfig(&TestLogger{})
+	err = conf.buildFromDockerLabels(map[string]map[string]string{
+		"some": {
+			requiredLabel: "true",
+			serviceLabel:  "true",
+			labelPrefix + "." + jobLocal + ".job1.schedule":       "@daily",
+			labelPrefix + "." + jobLocal + ".job1.webhook-url":    "https://example.com/hook",
+			labelPrefix + "." + jobLocal + ".job1.webhook-header": "Authorization: Bearer foo",
+		},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(conf.LocalJobs["job1"].WebhookHeaders, DeepEquals, []string{"Authorization: Bearer foo"})
+}
+
 func (s *SuiteConfig) TestJobDefaultsSet(c *C) {
 	j := &RunJobConfig{}
 	j.Pull = "false"
@@ -76,6 +224,180 @@ func (s *SuiteConfig) TestExecJobBuild(c *C) {
 	c.Assert(j.Middlewares(), HasLen, 1)
 }
 
+func (s *SuiteConfig) TestDockerLabelsUpdateRemoved(c *C) {
+	config := NewConfig(&TestLogger{})
+	c.Assert(config.InitializeApp(true), IsNil)
+
+	j := &ExecJobConfig{FromDockerLabel: true}
+	j.Name = "cache"
+	j.Schedule = "@daily"
+	config.ExecJobs["cache"] = j
+	c.Assert(config.sh.AddJob(j), IsNil)
+
+	// the labels could not be read
+	config.dockerLabelsUpdate(nil)
+	c.Assert(config.ExecJobs, HasLen, 1)
+
+	// no container has jobs anymore
+	config.dockerLabelsUpdate(map[string]map[string]string{})
+	c.Assert(config.ExecJobs, HasLen, 0)
+	c.Assert(config.sh.GetJobs(), HasLen, 0)
+}
+
+func (s *SuiteConfig) TestDockerLabelsUpdateRunJobs(c *C) {
+	config := NewConfig(&TestLogger{})
+	c.Assert(config.InitializeApp(true), IsNil)
+	config.useOfficialDocker = true
+	config.officialDockerHandler = &OfficialDockerHandler{dockerClient: &core.MockDockerClient{}}
+
+	file := &RunJobConfig{}
+	file.Name = "file"
+	file.Schedule = "@daily"
+	file.Image = "alpine"
+	config.RunJobs["file"] = file
+	c.Assert(config.sh.AddJob(file), IsNil)
+
+	worker := map[string]string{
+		requiredLabel: "true",
+		labelPrefix + "." + jobRun + ".cleanup.schedule": "@daily",
+		labelPrefix + "." + jobRun + ".cleanup.image":    "busybox",
+	}
+
+	chadburn := map[string]string{
+		requiredLabel: "true",
+		serviceLabel:  "true",
+		labelPrefix + "." + jobServiceRun + ".report.schedule": "@daily",
+		labelPrefix + "." + jobServiceRun + ".report.image":    "alpine",
+	}
+
+	config.dockerLabelsUpdate(map[string]map[string]string{"worker": worker, "chadburn": chadburn})
+	c.Assert(config.RunJobs, HasLen, 2)
+	c.Assert(config.RunJobs["cleanup"].Container, Equals, "worker")
+	c.Assert(config.RunJobs["cleanup"].FromDockerLabel, Equals, true)
+	c.Assert(config.ServiceJobs, HasLen, 1)
+	c.Assert(config.sh.GetJobs(), HasLen, 3)
+
+	// a modified job is replaced, the others are kept
+	cleanup, report := config.sh.GetJob("cleanup"), config.sh.GetJob("report")
+	worker[labelPrefix+"."+jobRun+".cleanup.schedule"] = "@hourly"
+	config.dockerLabelsUpdate(map[string]map[string]string{"worker": worker, "chadburn": chadburn})
+	c.Assert(config.sh.GetJob("cleanup"), Not(Equals), cleanup)
+	c.Assert(config.sh.GetJob("cleanup").GetSchedule(), Equals, "@hourly")
+	c.Assert(config.sh.GetJob("report"), Equals, report)
+
+	// the jobs of a removed container are removed, and added back with it
+	config.dockerLabelsUpdate(map[string]map[string]string{"chadburn": chadburn})
+	c.Assert(config.RunJobs, HasLen, 1)
+	c.Assert(config.sh.GetJob("cleanup"), IsNil)
+	c.Assert(config.sh.GetJobs(), HasLen, 2)
+
+	config.dockerLabelsUpdate(map[string]map[string]string{"worker": worker, "chadburn": chadburn})
+	c.Assert(config.RunJobs, HasLen, 2)
+	c.Assert(config.sh.GetJob("cleanup"), NotNil)
+
+	// the jobs of the configuration file are never removed
+	config.dockerLabelsUpdate(map[string]map[string]string{})
+	c.Assert(config.RunJobs, HasLen, 1)
+	c.Assert(config.RunJobs["file"], Equals, file)
+	c.Assert(config.ServiceJobs, HasLen, 0)
+	c.Assert(config.sh.GetJobs(), HasLen, 1)
+}
+
+func (s *SuiteConfig) TestDockerLabelsUpdateLocalJobs(c *C) {
+	config := NewConfig(&TestLogger{})
+	c.Assert(config.InitializeApp(true), IsNil)
+
+	chadburn := map[string]string{
+		requiredLabel: "true",
+		serviceLabel:  "true",
+		labelPrefix + "." + jobLocal + ".backup.schedule": "@daily",
+		labelPrefix + "." + jobLocal + ".backup.command":  "true",
+	}
+
+	config.dockerLabelsUpdate(map[string]map[string]string{"chadburn": chadburn})
+	c.Assert(config.LocalJobs, HasLen, 1)
+	c.Assert(config.sh.GetJobs(), HasLen, 1)
+
+	// a modified job is replaced
+	backup := config.sh.GetJob("backup")
+	chadburn[labelPrefix+"."+jobLocal+".backup.schedule"] = "@hourly"
+	config.dockerLabelsUpdate(map[string]map[string]string{"chadburn": chadburn})
+	c.Assert(config.sh.GetJob("backup"), Not(Equals), backup)
+	c.Assert(config.sh.GetJob("backup").GetSchedule(), Equals, "@hourly")
+	c.Assert(config.sh.GetJobs(), HasLen, 1)
+
+	// labels that cannot be decoded keep the running jobs
+	backup = config.sh.GetJob("backup")
+	chadburn[labelPrefix+"."+jobLocal+".backup.no-overlap"] = "maybe"
+	config.dockerLabelsUpdate(map[string]map[string]string{"chadburn": chadburn})
+	c.Assert(config.sh.GetJob("backup"), Equals, backup)
+	c.Assert(config.LocalJobs, HasLen, 1)
+}
+
+func (s *SuiteConfig) TestDockerLabelsPrefix(c *C) {
+	client := &labelsDockerClient{containers: []core.Container{{Name: "web", Labels: map[string]string{
+		"ops.enabled":                  "true",
+		"ops.job-exec.cache.schedule":  "@hourly",
+		"chadburn.job-exec.a.schedule": "@daily",
+		"other":                        "label",
+	}}}}
+
+	labels, err := getDockerLabels(client, LabelOptions{LabelPrefix: "ops"})
+	c.Assert(err, IsNil)
+	c.Assert(labels, DeepEquals, map[string]map[string]string{"web": {
+		requiredLabel: "true",
+		labelPrefix + "." + jobExec + ".cache.schedule": "@hourly",
+	}})
+
+	_, err = getDockerLabels(client, LabelOptions{LabelPrefix: "data"})
+	c.Assert(err, Equals, ErrNoContainerWithChadburnEnabled)
+}
+
+func (s *SuiteConfig) TestDockerLabelsInstance(c *C) {
+	container := func(name, instance string) core.Container {
+		labels := map[string]string{requiredLabel: "true"}
+		if instance != "" {
+			labels[instanceLabel] = instance
+		}
+
+		return core.Container{Name: name, Labels: labels}
+	}
+
+	client := &labelsDockerClient{containers: []core.Container{
+		container("web", "prod-ops"), container("etl", "data-team"), container("db", ""),
+	}}
+
+	for instance, expected := range map[string]string{"prod-ops": "web", "data-team": "etl", "": "db"} {
+		labels, err := getDockerLabels(client, LabelOptions{Instance: instance})
+		c.Assert(err, IsNil)
+		c.Assert(labels, HasLen, 1)
+		c.Assert(labels[expected], DeepEquals, map[string]string{requiredLabel: "true"})
+	}
+}
+
+func (s *SuiteConfig) TestDockerLabelsOfelia(c *C) {
+	client := &labelsDockerClient{containers: []core.Container{{Name: "web", Labels: map[string]string{
+		"ofelia.enabled":                  "true",
+		"ofelia.job-exec.cache.schedule":  "@hourly",
+		"ofelia.job-exec.cache.command":   "clear-cache",
+		"chadburn.job-exec.cache.command": "clear-all",
+	}}}}
+
+	labels, err := getDockerLabels(client, LabelOptions{Ofelia: true})
+	c.Assert(err, IsNil)
+
+	config := NewConfig(&TestLogger{})
+	c.Assert(config.buildFromDockerLabels(labels), IsNil)
+	c.Assert(config.ExecJobs["cache"].Schedule, Equals, "@hourly")
+	c.Assert(config.ExecJobs["cache"].Command, Equals, "clear-all")
+	c.Assert(config.ExecJobs["cache"].Container, Equals, "web")
+
+	// the Ofelia labels are only read in the compatible mode
+	delete(client.containers[0].Labels, "chadburn.job-exec.cache.command")
+	_, err = getDockerLabels(client, LabelOptions{})
+	c.Assert(err, Equals, ErrNoContainerWithChadburnEnabled)
+}
+
 func (s *SuiteConfig) TestLabelsConfig(c *C) {
 	testcases := []struct {
 		Labels         map[string]map[string]string
@@ -148,26 +470,32 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 								Command:  "command1",
 							},
 						},
-						FromDockerLabel: false,
+						FromDockerLabel: true,
 					},
 				},
 				RunJobs: map[string]*RunJobConfig{
 					"job2": &RunJobConfig{RunJob: core.RunJob{BareJob: core.BareJob{
 						Schedule: "schedule2",
 						Command:  "command2",
-					}}},
+					}},
+						FromDockerLabel: true,
+					},
 					"job5": &RunJobConfig{RunJob: core.RunJob{BareJob: core.BareJob{
 						Schedule: "schedule5",
 						Command:  "command5",
 					},
 						Container: "other",
-					}},
+					},
+						FromDockerLabel: true,
+					},
 				},
 				ServiceJobs: map[string]*RunServiceConfig{
 					"job3": &RunServiceConfig{RunServiceJob: core.RunServiceJob{BareJob: core.BareJob{
 						Schedule: "schedule3",
 						Command:  "command3",
-					}}},
+					}},
+						FromDockerLabel: true,
+					},
 				},
 			},
 			Comment: "Local jobs from non-service container ignored, but job-run labels on target containers are supported",
@@ -255,6 +583,7 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 					},
 						Volume: []string{"/test/tmp:/test/tmp:ro"},
 					},
+						FromDockerLabel: true,
 					},
 					"job2": {RunJob: core.RunJob{BareJob: core.BareJob{
 						Schedule: "schedule2",
@@ -262,6 +591,7 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 					},
 						Volume: []string{"/test/tmp:/test/tmp:ro", "/test/tmp:/test/tmp:rw"},
 					},
+						FromDockerLabel: true,
 					},
 				},
 			},
@@ -287,7 +617,7 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 								Command:  "rm -rf *test*",
 							},
 						},
-						FromDockerLabel: false,
+						FromDockerLabel: true,
 					},
 					"job2": &LocalJobConfig{
 						LocalJob: core.LocalJob{
@@ -296,7 +626,7 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 								Command:  "ls -al *test*",
 							},
 						},
-						FromDockerLabel: false,
+						FromDockerLabel: true,
 					},
 				},
 			},
@@ -311,3 +641,63 @@ func (s *SuiteConfig) TestLabelsConfig(c *C) {
 		c.Assert(conf, DeepEquals, t.ExpectedConfig)
 	}
 }
+
+func (s *SuiteConfig) TestNotifyConfig(c *C) {
+	config, err := BuildFromString(`
+		[global]
+		notify-on = change
+
+		[job-local "report"]
+		schedule = @daily
+		command = true
+		notify-rate-limit = 5/1h
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.NotifyOn, Equals, "change")
+	c.Assert(config.LocalJobs["report"].NotifyRateLimit, Equals, "5/1h")
+
+	conf := NewConfig(&TestLogger{})
+	err = conf.buildFromDockerLabels(map[string]map[string]string{
+		"some": {
+			requiredLabel:              "true",
+			serviceLabel:               "true",
+			labelPrefix + ".notify-on": "change",
+			labelPrefix + "." + jobLocal + ".job1.schedule":  "@daily",
+			labelPrefix + "." + jobLocal + ".job1.notify-on": "always",
+		},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(conf.Global.NotifyOn, Equals, "change")
+	c.Assert(conf.LocalJobs["job1"].NotifyOn, Equals, "always")
+}
+
+func (s *SuiteConfig) TestPingConfig(c *C) {
+	config, err := BuildFromString(`
+		[global]
+		ping-url = https://hc-ping.com/key/{{.Job.GetName}}
+
+		[job-local "report"]
+		schedule = @daily
+		command = true
+		ping-success-url = https://kuma.example.com/api/push/token?status=up
+  `, &TestLogger{})
+
+	c.Assert(err, IsNil)
+	c.Assert(config.Global.PingURL, Equals, "https://hc-ping.com/key/{{.Job.GetName}}")
+	c.Assert(config.LocalJobs["report"].PingSuccessURL, Equals, "https://kuma.example.com/api/push/token?status=up")
+
+	conf := NewConfig(&TestLogger{})
+	err = conf.buildFromDockerLabels(map[string]map[string]string{
+		"some": {
+			requiredLabel: "true",
+			serviceLabel:  "true",
+			labelPrefix + "." + jobLocal + ".job1.schedule": "@daily",
+			labelPrefix + "." + jobLocal + ".job1.ping-url": "https://hc-ping.com/uuid",
+		},
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(conf.LocalJobs["job1"].PingURL, Equals, "https://hc-ping.com/uuid")
+}
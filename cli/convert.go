@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/PremoWeb/Chadburn/core"
+	gcfg "gopkg.in/gcfg.v1"
+)
+
+// ConvertCommand converts a configuration file to another format, eg. to
+// migrate an INI file to YAML
+type ConvertCommand struct {
+	To     string `long:"to" description:"format of the converted file" choice:"yaml" choice:"toml" choice:"ini" default:"yaml"`
+	Output string `long:"output" short:"o" description:"file to write, the standard output if not set"`
+	Args   struct {
+		File string `positional-arg-name:"file" description:"configuration file to convert, in INI, YAML or TOML format"`
+	} `positional-args:"yes" required:"yes"`
+	Logger core.Logger
+
+	output io.Writer
+}
+
+// Execute runs the convert command, the unknown options of the file are
+// reported and dropped. Only the given file is converted, not its includes.
+func (c *ConvertCommand) Execute(args []string) error {
+	config, err := readConfigFile(c.Args.File, c.Logger)
+	if err != nil {
+		if gcfg.FatalOnly(err) != nil {
+			return err
+		}
+
+		c.Logger.Warningf("Options not converted: %s", err)
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := config.encode(c.To, b); err != nil {
+		return err
+	}
+
+	if c.Output != "" {
+		return ioutil.WriteFile(c.Output, b.Bytes(), 0644)
+	}
+
+	if c.output == nil {
+		c.output = os.Stdout
+	}
+
+	_, err = c.output.Write(b.Bytes())
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/PremoWeb/Chadburn/core"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -14,14 +15,21 @@ import (
 
 // DaemonCommand daemon process
 type DaemonCommand struct {
-	ConfigFile    string `long:"config" description:"configuration file" default:"/etc/chadburn.conf"`
-	Metrics       bool   `long:"metrics" description:"Enable Prometheus compatible metrics endpoint"`
-	MetricsAddr   string `long:"listen-address" description:"Metrics endpoint listen address." default:":8080"`
-	DisableDocker bool   `long:"disable-docker" description:"Disable docker integration. All job kinds except 'job-local' will be ignored"`
+	ConfigFile    string        `long:"config" description:"configuration file or directory" default:"/etc/chadburn.conf"`
+	Metrics       bool          `long:"metrics" description:"Enable Prometheus compatible metrics endpoint"`
+	MetricsAddr   string        `long:"listen-address" description:"Metrics and API endpoints listen address." default:":8080"`
+	API           bool          `long:"api" description:"Enable the HTTP control API under /api/v1"`
+	APIToken      string        `long:"api-token" env:"CHADBURN_API_TOKEN" description:"Bearer token required to access the HTTP control API"`
+	DisableDocker bool          `long:"disable-docker" description:"Disable docker integration. All job kinds except 'job-local' will be ignored"`
+	ConfigPoll    time.Duration `long:"config-poll-interval" description:"How often the configuration file is checked for changes, 0 to only reload on SIGHUP" default:"10s"`
+	DockerPoll    time.Duration `long:"docker-poll-interval" description:"How often the jobs of the Docker labels are fully resynchronized besides the container events, 0 to only follow the events" default:"1m"`
 	scheduler     *core.Scheduler
+	config        *Config
 	signals       chan os.Signal
 	done          chan bool
+	stopWatch     chan struct{}
 	Logger        core.Logger
+	LabelOptions
 }
 
 // Execute runs the daemon
@@ -48,19 +56,40 @@ func (c *DaemonCommand) boot() (err error) {
 		c.Logger.Debugf("Cannot read config file: %q", err)
 	}
 
+	config.dockerPoll = c.DockerPoll
+	config.labelOptions = c.LabelOptions
 	err = config.InitializeApp(c.DisableDocker)
 	if err != nil {
 		c.Logger.Criticalf("Can't start the app: %v", err)
 	}
 	c.scheduler = config.sh
+	c.config = config
 
 	return err
 }
 
+// reload applies the changes of the configuration file, keeping the running
+// configuration if the file is not valid
+func (c *DaemonCommand) reload() {
+	c.Logger.Noticef("Reloading configuration file %q", c.ConfigFile)
+	if err := c.config.Reload(c.ConfigFile); err != nil {
+		c.Logger.Errorf("Cannot reload the configuration, keeping the running one: %s", err)
+	}
+}
+
 func startHttpServer(c *DaemonCommand, wg *sync.WaitGroup) *http.Server {
-	c.Logger.Debugf("Starting metrics on %s", c.MetricsAddr)
-	srv := &http.Server{Addr: c.MetricsAddr}
-	http.Handle("/metrics", promhttp.Handler())
+	mux := http.NewServeMux()
+	if c.Metrics {
+		c.Logger.Debugf("Starting metrics on %s", c.MetricsAddr)
+		mux.Handle("/metrics", promhttp.Handler())
+	}
+
+	if c.API {
+		c.Logger.Debugf("Starting API on %s%s", c.MetricsAddr, apiPrefix)
+		mux.Handle(apiPrefix, newAPIHandler(c.config, c.APIToken, c.Logger))
+	}
+
+	srv := &http.Server{Addr: c.MetricsAddr, Handler: mux}
 
 	go func() {
 		defer wg.Done()
@@ -68,7 +97,7 @@ func startHttpServer(c *DaemonCommand, wg *sync.WaitGroup) *http.Server {
 		// always returns error. ErrServerClosed on graceful close
 		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 			// unexpected error. port in use?
-			c.Logger.Errorf("Metrics serving failed: %v", err)
+			c.Logger.Errorf("HTTP serving failed: %v", err)
 		}
 	}()
 
@@ -77,7 +106,7 @@ func startHttpServer(c *DaemonCommand, wg *sync.WaitGroup) *http.Server {
 }
 
 func (c *DaemonCommand) start() error {
-	if c.Metrics {
+	if c.Metrics || c.API {
 		httpServerExitDone := &sync.WaitGroup{}
 		httpServerExitDone.Add(1)
 		srv := startHttpServer(c, httpServerExitDone)
@@ -90,6 +119,11 @@ func (c *DaemonCommand) start() error {
 		return err
 	}
 
+	c.stopWatch = make(chan struct{})
+	if c.ConfigPoll > 0 {
+		go watchConfigFile(c.config.watchedPaths, c.ConfigPoll, c.stopWatch, c.reload)
+	}
+
 	return nil
 }
 
@@ -97,24 +131,32 @@ func (c *DaemonCommand) setSignals(srv *http.Server) {
 	c.signals = make(chan os.Signal, 1)
 	c.done = make(chan bool, 1)
 
-	signal.Notify(c.signals, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(c.signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go func() {
-		sig := <-c.signals
-		c.Logger.Warningf(
-			"Signal received: %s, shutting down the process\n", sig,
-		)
-		if srv != nil {
-			if err := srv.Shutdown(context.TODO()); err != nil {
-				panic(err) // failure/timeout shutting down the server gracefully
+		for sig := range c.signals {
+			if sig == syscall.SIGHUP {
+				c.reload()
+				continue
+			}
+
+			c.Logger.Warningf(
+				"Signal received: %s, shutting down the process\n", sig,
+			)
+			if srv != nil {
+				if err := srv.Shutdown(context.TODO()); err != nil {
+					panic(err) // failure/timeout shutting down the server gracefully
+				}
 			}
+			c.done <- true
+			return
 		}
-		c.done <- true
 	}()
 }
 
 func (c *DaemonCommand) shutdown() error {
 	<-c.done
+	close(c.stopWatch)
 	if !c.scheduler.IsRunning() {
 		return nil
 	}
@@ -9,15 +9,67 @@ import (
 	"github.com/mitchellh/mapstructure"
 )
 
+// The labels are read with the chadburn prefix whatever the prefix set in
+// LabelOptions, getDockerLabels renames them
 const (
 	labelPrefix    = "chadburn"
 	LabelNamespace = "chadburn"
 
-	requiredLabel       = labelPrefix + ".enabled"
-	requiredLabelFilter = requiredLabel + "=true"
-	serviceLabel        = labelPrefix + ".service"
+	requiredLabel = labelPrefix + ".enabled"
+	serviceLabel  = labelPrefix + ".service"
+	instanceLabel = labelPrefix + ".instance"
 )
 
+// ofeliaLabelPrefix is the prefix of the labels of Ofelia, read as well in
+// the Ofelia compatible mode
+const ofeliaLabelPrefix = "ofelia"
+
+// LabelOptions select the Docker labels defining the jobs, to run several
+// instances on the same Docker host
+type LabelOptions struct {
+	LabelPrefix string `long:"label-prefix" env:"CHADBURN_LABEL_PREFIX" description:"Prefix of the Docker labels defining the jobs" default:"chadburn"`
+	Instance    string `long:"instance" env:"CHADBURN_INSTANCE" description:"Only read the containers with the <prefix>.instance label set to this name"`
+	Ofelia      bool   `long:"ofelia-labels" description:"Also read the ofelia.* labels, for the containers configured for Ofelia"`
+}
+
+// prefixes returns the prefixes of the labels read, by priority
+func (o LabelOptions) prefixes() []string {
+	prefix := strings.TrimSuffix(o.LabelPrefix, ".")
+	if prefix == "" {
+		prefix = labelPrefix
+	}
+
+	prefixes := []string{prefix}
+	if o.Ofelia && prefix != ofeliaLabelPrefix {
+		prefixes = append(prefixes, ofeliaLabelPrefix)
+	}
+
+	return prefixes
+}
+
+// containerLabels returns the labels of a container with the chadburn
+// prefix, nil if the container is addressed to another instance
+func (o LabelOptions) containerLabels(labels map[string]string) map[string]string {
+	prefixes := o.prefixes()
+	renamed := make(map[string]string)
+
+	// the labels of the main prefix win over the ones of Ofelia
+	for i := len(prefixes) - 1; i >= 0; i-- {
+		for k, v := range labels {
+			if strings.HasPrefix(k, prefixes[i]+".") {
+				renamed[labelPrefix+k[len(prefixes[i]):]] = v
+			}
+		}
+	}
+
+	if renamed[instanceLabel] != o.Instance {
+		return nil
+	}
+
+	delete(renamed, instanceLabel)
+	return renamed
+}
+
 func (c *Config) buildFromDockerLabels(labels map[string]map[string]string) error {
 	execJobs := make(map[string]map[string]interface{})
 	localJobs := make(map[string]map[string]interface{})
@@ -63,17 +115,19 @@ func (c *Config) buildFromDockerLabels(labels map[string]map[string]string) erro
 			case jobType == jobLocal && isServiceContainer:
 				if _, ok := localJobs[jobName]; !ok {
 					localJobs[jobName] = make(map[string]interface{})
-					localJobs[jobName]["fromDockerLabel"] = false
+					localJobs[jobName]["fromDockerLabel"] = true
 				}
 				setJobParam(localJobs[jobName], jopParam, v)
 			case jobType == jobServiceRun && isServiceContainer:
 				if _, ok := serviceJobs[jobName]; !ok {
 					serviceJobs[jobName] = make(map[string]interface{})
+					serviceJobs[jobName]["fromDockerLabel"] = true
 				}
 				setJobParam(serviceJobs[jobName], jopParam, v)
 			case jobType == jobRun:
 				if _, ok := runJobs[jobName]; !ok {
 					runJobs[jobName] = make(map[string]interface{})
+					runJobs[jobName]["fromDockerLabel"] = true
 				}
 				setJobParam(runJobs[jobName], jopParam, v)
 				// If the label is on a non-service container, set the container name
@@ -133,6 +187,43 @@ func (c *Config) buildFromDockerLabels(labels map[string]map[string]string) erro
 	return nil
 }
 
+// getDockerLabels returns the labels of the containers with jobs, by
+// container name, renamed with the chadburn prefix
+func getDockerLabels(client core.DockerClient, opts LabelOptions) (map[string]map[string]string, error) {
+	// Get the containers with the required label, or with job-run labels
+	contMap := make(map[string]core.Container)
+	for _, prefix := range opts.prefixes() {
+		for _, filter := range []string{prefix + ".enabled=true", prefix + "." + jobRun} {
+			containers, err := client.ListContainers(map[string][]string{
+				"label": {filter},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, cont := range containers {
+				contMap[cont.Name] = cont
+			}
+		}
+	}
+
+	var labels = make(map[string]map[string]string)
+
+	for name, c := range contMap {
+		// only include relevant labels
+		containerLabels := opts.containerLabels(c.Labels)
+		if len(containerLabels) > 0 {
+			labels[name] = containerLabels
+		}
+	}
+
+	if len(labels) == 0 {
+		return nil, ErrNoContainerWithChadburnEnabled
+	}
+
+	return labels, nil
+}
+
 func setJobParam(params map[string]interface{}, paramName, paramVal string) {
 	switch paramName {
 	case "volume":
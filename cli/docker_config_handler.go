@@ -17,6 +17,7 @@ type DockerHandler struct {
 	notifier      dockerLabelsUpdate
 	logger        core.Logger
 	lifecycleJobs map[string]*LifecycleJobConfig // Map of lifecycle jobs
+	labels        *labelsWatcher
 	ctx           context.Context
 	cancel        context.CancelFunc
 }
@@ -40,7 +41,7 @@ func (c *DockerHandler) GetInternalDockerClient() core.DockerClient {
 	return c.dockerClient
 }
 
-func NewDockerHandler(notifier dockerLabelsUpdate, logger core.Logger) (*DockerHandler, error) {
+func NewDockerHandler(notifier dockerLabelsUpdate, logger core.Logger, poll time.Duration, labels LabelOptions) (*DockerHandler, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create a new official Docker client
@@ -55,54 +56,16 @@ func NewDockerHandler(notifier dockerLabelsUpdate, logger core.Logger) (*DockerH
 		notifier:      notifier,
 		logger:        logger,
 		lifecycleJobs: make(map[string]*LifecycleJobConfig),
+		labels:        newLabelsWatcher(client, notifier, logger, poll, labels),
 		ctx:           ctx,
 		cancel:        cancel,
 	}
 
-	go c.watch()
+	go c.labels.watch(ctx)
 	go c.watchEvents() // Start watching Docker events
 	return c, nil
 }
 
-// watch polls for changes in Docker containers
-func (c *DockerHandler) watch() {
-	// Poll for changes
-	tick := time.Tick(10000 * time.Millisecond)
-
-	// Add backoff for connection issues
-	connectionBackoff := 100 * time.Millisecond
-	maxConnectionBackoff := 5 * time.Second
-
-	for {
-		select {
-		case <-tick:
-			labels, err := c.GetDockerLabels()
-			// Do not print or care if there is no container up right now
-			if err != nil && !errors.Is(err, ErrNoContainerWithChadburnEnabled) {
-				c.logger.Debugf("%v", err)
-
-				// Check if it's a connection error
-				if strings.Contains(err.Error(), "Cannot connect to the Docker daemon") {
-					// Apply exponential backoff
-					if connectionBackoff < maxConnectionBackoff {
-						connectionBackoff *= 2
-					}
-					c.logger.Noticef("Docker daemon connection issue. Waiting %v before next attempt...", connectionBackoff)
-					time.Sleep(connectionBackoff)
-					continue
-				}
-			} else {
-				// Reset backoff on success
-				connectionBackoff = 100 * time.Millisecond
-			}
-
-			c.notifier.dockerLabelsUpdate(labels)
-		case <-c.ctx.Done():
-			return
-		}
-	}
-}
-
 // watchEvents listens for Docker events and triggers lifecycle jobs
 func (c *DockerHandler) watchEvents() {
 	// Add a backoff mechanism to prevent rapid restarts
@@ -118,6 +81,7 @@ func (c *DockerHandler) watchEvents() {
 		c.dockerClient.WatchEvents(c.ctx, eventCh, errCh)
 
 		c.logger.Noticef("Started watching Docker events")
+		c.labels.schedule()
 
 		// Check if context is done
 		select {
@@ -140,6 +104,10 @@ func (c *DockerHandler) watchEvents() {
 					continue
 				}
 
+				// The removed containers cannot be inspected, the jobs of
+				// the labels are refreshed first
+				c.labels.event(event.Action)
+
 				// Get container info
 				container, err := c.dockerClient.InspectContainer(event.ID)
 				if err != nil {
@@ -204,52 +172,5 @@ func (c *DockerHandler) processLifecycleEvent(containerName, containerID string,
 }
 
 func (c *DockerHandler) GetDockerLabels() (map[string]map[string]string, error) {
-	// First, get containers with the required label
-	conts, err := c.dockerClient.ListContainers(map[string][]string{
-		"label": {requiredLabelFilter},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Also get containers with job-run labels
-	jobRunConts, err := c.dockerClient.ListContainers(map[string][]string{
-		"label": {labelPrefix + "." + jobRun},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Combine the two lists, avoiding duplicates
-	contMap := make(map[string]core.Container)
-	for _, cont := range conts {
-		contMap[cont.Name] = cont
-	}
-	for _, cont := range jobRunConts {
-		contMap[cont.Name] = cont
-	}
-
-	if len(contMap) == 0 {
-		return nil, ErrNoContainerWithChadburnEnabled
-	}
-
-	var labels = make(map[string]map[string]string)
-
-	for name, c := range contMap {
-		if len(c.Labels) > 0 {
-			containerLabels := make(map[string]string)
-			for k, v := range c.Labels {
-				// only include relevant labels
-				if strings.HasPrefix(k, labelPrefix) {
-					containerLabels[k] = v
-				}
-			}
-
-			if len(containerLabels) > 0 {
-				labels[name] = containerLabels
-			}
-		}
-	}
-
-	return labels, nil
+	return getDockerLabels(c.dockerClient, c.labels.options)
 }
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+// defaultDockerPoll is how often the jobs of the Docker labels are fully
+// resynchronized, as a safety net for the missed container events
+const defaultDockerPoll = time.Minute
+
+// labelsRefreshDelay groups the events of a deployment, eg. a container
+// created then started, in a single refresh
+const labelsRefreshDelay = 200 * time.Millisecond
+
+// labelsEvents are the container events that may add or remove jobs
+var labelsEvents = map[string]bool{
+	"create":  true,
+	"start":   true,
+	"die":     true,
+	"destroy": true,
+	"rename":  true,
+}
+
+// labelsWatcher refreshes the jobs of the Docker labels when the containers
+// change, and periodically if interval is not zero
+type labelsWatcher struct {
+	client   core.DockerClient
+	notifier dockerLabelsUpdate
+	logger   core.Logger
+	interval time.Duration
+	options  LabelOptions
+	changes  chan struct{}
+}
+
+func newLabelsWatcher(client core.DockerClient, notifier dockerLabelsUpdate, logger core.Logger, interval time.Duration, options LabelOptions) *labelsWatcher {
+	return &labelsWatcher{
+		client:   client,
+		notifier: notifier,
+		logger:   logger,
+		interval: interval,
+		options:  options,
+		changes:  make(chan struct{}, 1),
+	}
+}
+
+// event schedules a refresh if the action of a container event may change
+// the jobs, without blocking the processing of the events
+func (w *labelsWatcher) event(action string) {
+	if labelsEvents[action] {
+		w.schedule()
+	}
+}
+
+// schedule refreshes the jobs shortly, eg. after a reconnection to Docker
+// since the events were missed
+func (w *labelsWatcher) schedule() {
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+// watch refreshes the jobs at start, after the container events and every
+// interval, until the context is done
+func (w *labelsWatcher) watch(ctx context.Context) {
+	w.refresh()
+
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-w.changes:
+			if delay == nil {
+				delay = time.After(labelsRefreshDelay)
+			}
+		case <-delay:
+			delay = nil
+			w.refresh()
+		case <-tick:
+			w.refresh()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *labelsWatcher) refresh() {
+	labels, err := getDockerLabels(w.client, w.options)
+	if errors.Is(err, ErrNoContainerWithChadburnEnabled) {
+		// Docker answered but no container has jobs anymore, the empty map
+		// removes the jobs of the last one, dockerLabelsUpdate skips only nil
+		labels, err = map[string]map[string]string{}, nil
+	}
+
+	if err != nil {
+		// the jobs are kept when Docker cannot be reached
+		w.logger.Debugf("Cannot read the Docker labels: %v", err)
+		return
+	}
+
+	w.notifier.dockerLabelsUpdate(labels)
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteLabelsWatcher struct {
+	client  *labelsDockerClient
+	updates chan map[string]map[string]string
+	cancel  context.CancelFunc
+}
+
+var _ = Suite(&SuiteLabelsWatcher{})
+
+// labelsDockerClient lists the containers set by the tests
+type labelsDockerClient struct {
+	core.MockDockerClient
+	sync.Mutex
+	containers []core.Container
+	err        error
+}
+
+func (c *labelsDockerClient) ListContainers(filters map[string][]string) ([]core.Container, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.containers, c.err
+}
+
+func (c *labelsDockerClient) setError(err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.err = err
+}
+
+func (c *labelsDockerClient) setContainers(names ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.containers = nil
+	for _, name := range names {
+		c.containers = append(c.containers, core.Container{Name: name, Labels: map[string]string{
+			requiredLabel: "true",
+			labelPrefix + "." + jobExec + "." + name + ".schedule": "@daily",
+			labelPrefix + "." + jobExec + "." + name + ".command":  "true",
+		}})
+	}
+}
+
+// labelsUpdates sends the labels of the updates to a channel, dropping them
+// once full
+type labelsUpdates chan map[string]map[string]string
+
+func (u labelsUpdates) dockerLabelsUpdate(labels map[string]map[string]string) {
+	select {
+	case u <- labels:
+	default:
+	}
+}
+
+func (s *SuiteLabelsWatcher) SetUpTest(c *C) {
+	s.client = &labelsDockerClient{}
+	s.client.setContainers("web")
+	s.updates = make(chan map[string]map[string]string, 10)
+}
+
+func (s *SuiteLabelsWatcher) TearDownTest(c *C) {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+func (s *SuiteLabelsWatcher) watch(interval time.Duration) *labelsWatcher {
+	w := newLabelsWatcher(s.client, labelsUpdates(s.updates), &TestLogger{}, interval, LabelOptions{})
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	go w.watch(ctx)
+
+	return w
+}
+
+func (s *SuiteLabelsWatcher) next(c *C) map[string]map[string]string {
+	select {
+	case labels := <-s.updates:
+		return labels
+	case <-time.After(time.Second):
+		c.Fatal("labels not refreshed")
+	}
+
+	return nil
+}
+
+func (s *SuiteLabelsWatcher) TestEvents(c *C) {
+	w := s.watch(0)
+	c.Assert(s.next(c), HasLen, 1)
+
+	s.client.setContainers("web", "db")
+	w.event("start")
+	w.event("create")
+	c.Assert(s.next(c), HasLen, 2)
+
+	// the events of a deployment are refreshed once
+	select {
+	case <-s.updates:
+		c.Fatal("labels refreshed twice")
+	case <-time.After(2 * labelsRefreshDelay):
+	}
+
+	// the events not changing the containers are ignored
+	w.event("exec_start")
+	select {
+	case <-s.updates:
+		c.Fatal("labels refreshed on exec_start")
+	case <-time.After(2 * labelsRefreshDelay):
+	}
+}
+
+func (s *SuiteLabelsWatcher) TestRemovedContainers(c *C) {
+	w := s.watch(0)
+	c.Assert(s.next(c), HasLen, 1)
+
+	s.client.setContainers()
+	w.event("destroy")
+
+	labels := s.next(c)
+	c.Assert(labels, NotNil)
+	c.Assert(labels, HasLen, 0)
+}
+
+func (s *SuiteLabelsWatcher) TestListError(c *C) {
+	w := s.watch(0)
+	c.Assert(s.next(c), HasLen, 1)
+
+	// unlike an empty list, an error doesn't remove the jobs
+	s.client.setError(errors.New("Cannot connect to the Docker daemon"))
+	w.event("destroy")
+	select {
+	case <-s.updates:
+		c.Fatal("labels refreshed on a list error")
+	case <-time.After(2 * labelsRefreshDelay):
+	}
+
+	s.client.setError(nil)
+	w.schedule()
+	c.Assert(s.next(c), HasLen, 1)
+}
+
+func (s *SuiteLabelsWatcher) TestPoll(c *C) {
+	s.watch(20 * time.Millisecond)
+	c.Assert(s.next(c), HasLen, 1)
+
+	// the refreshes made before the change are skipped
+	s.client.setContainers("web", "db")
+	for len(s.next(c)) != 2 {
+	}
+}
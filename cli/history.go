@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/PremoWeb/Chadburn/history"
+)
+
+// HistoryCommand shows the executions recorded by the history store
+type HistoryCommand struct {
+	ConfigFile string `long:"config" description:"configuration file" default:"/etc/chadburn.conf"`
+	Backend    string `long:"backend" description:"history backend (file or bolt), overrides the configuration file"`
+	Path       string `long:"path" description:"history location, overrides the configuration file"`
+	Job        string `long:"job" description:"only show the executions of this job"`
+	Since      string `long:"since" description:"only show the executions started in this period of time, eg.: 24h"`
+	Limit      int    `long:"limit" description:"maximum number of executions to show" default:"20"`
+	JSON       bool   `long:"json" description:"print the executions as JSON"`
+	Args       struct {
+		ID string `positional-arg-name:"execution-id" description:"show the details and output of an execution"`
+	} `positional-args:"yes"`
+	Logger core.Logger
+	output io.Writer
+}
+
+// Execute runs the history command
+func (c *HistoryCommand) Execute(args []string) error {
+	if c.output == nil {
+		c.output = os.Stdout
+	}
+
+	store, err := c.openStore()
+	if err != nil {
+		return err
+	}
+
+	if c.Args.ID != "" {
+		r, err := store.Get(c.Args.ID)
+		if err != nil {
+			return err
+		}
+
+		return c.printRecord(r)
+	}
+
+	q := history.Query{Job: c.Job, Limit: c.Limit}
+	if c.Since != "" {
+		d, err := time.ParseDuration(c.Since)
+		if err != nil {
+			return err
+		}
+
+		q.Since = time.Now().Add(-d)
+	}
+
+	records, err := store.List(q)
+	if err != nil {
+		return err
+	}
+
+	return c.printRecords(records)
+}
+
+func (c *HistoryCommand) openStore() (history.Store, error) {
+	config, err := BuildFromFile(c.ConfigFile, c.Logger)
+	if err != nil {
+		c.Logger.Debugf("Cannot read config file: %q", err)
+	}
+
+	hc := config.Global.HistoryConfig
+	if c.Backend != "" {
+		hc.HistoryBackend = c.Backend
+	}
+
+	if c.Path != "" {
+		hc.HistoryPath = c.Path
+	}
+
+	return hc.OpenStore()
+}
+
+func (c *HistoryCommand) printRecords(records []*history.Record) error {
+	if c.JSON {
+		if records == nil {
+			records = []*history.Record{}
+		}
+
+		return c.printJSON(records)
+	}
+
+	w := tabwriter.NewWriter(c.output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tJOB\tSTART\tDURATION\tSTATUS\tERROR")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Job, r.Start.Format("2006-01-02 15:04:05"), r.Duration, r.Status(), r.Error,
+		)
+	}
+
+	return w.Flush()
+}
+
+func (c *HistoryCommand) printRecord(r *history.Record) error {
+	if c.JSON {
+		return c.printJSON(r)
+	}
+
+	w := tabwriter.NewWriter(c.output, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", r.ID)
+	fmt.Fprintf(w, "Job:\t%s\n", r.Job)
+	fmt.Fprintf(w, "Command:\t%s\n", r.Command)
+	fmt.Fprintf(w, "Start:\t%s\n", r.Start.Format(time.RFC3339))
+	fmt.Fprintf(w, "End:\t%s\n", r.End.Format(time.RFC3339))
+	fmt.Fprintf(w, "Duration:\t%s\n", r.Duration)
+	fmt.Fprintf(w, "Status:\t%s\n", r.Status())
+	if !r.Skipped {
+		fmt.Fprintf(w, "Exit code:\t%d\n", r.ExitCode)
+	}
+
+	if r.Signal != "" {
+		fmt.Fprintf(w, "Signal:\t%s\n", r.Signal)
+	}
+
+	if r.Restarted {
+		fmt.Fprintf(w, "Restarted:\tcontainer restarted to stop the command\n")
+	}
+
+	if r.KillError != "" {
+		fmt.Fprintf(w, "Kill error:\t%s, the command may still be running\n", r.KillError)
+	}
+
+	if r.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", r.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.output, "\n--- stdout ---\n%s\n--- stderr ---\n%s\n", r.Stdout, r.Stderr)
+	return nil
+}
+
+func (c *HistoryCommand) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
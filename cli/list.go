@@ -0,0 +1,367 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	defaults "github.com/mcuadros/go-defaults"
+)
+
+// sourceLabels is the source of the jobs read from the Docker labels
+const sourceLabels = "docker-labels"
+
+// jobInfo describes a configured job, as shown by the list and next commands
+type jobInfo struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Schedule    string   `json:"schedule"`
+	Timezone    string   `json:"timezone,omitempty"`
+	Target      string   `json:"target,omitempty"`
+	Source      string   `json:"source"`
+	Middlewares []string `json:"middlewares"`
+
+	job core.Job
+}
+
+// jobLoader reads the jobs of the configuration file and of the labels of the
+// running containers, for the commands inspecting the configuration
+type jobLoader struct {
+	ConfigFile    string `long:"config" description:"configuration file or directory" default:"/etc/chadburn.conf"`
+	DisableDocker bool   `long:"disable-docker" description:"Do not read the jobs from the labels of the running containers"`
+	JSON          bool   `long:"json" description:"print the output as JSON"`
+	LabelOptions
+	Logger core.Logger
+
+	output io.Writer
+	// labels returns the labels of the running containers, read from Docker
+	// if nil
+	labels func() (map[string]map[string]string, error)
+}
+
+// load returns the jobs sorted by name and the scheduler, with the global
+// options and middlewares, the jobs would be added to
+func (c *jobLoader) load() ([]*jobInfo, *core.Scheduler, error) {
+	if c.output == nil {
+		c.output = os.Stdout
+	}
+
+	config, err := BuildFromFile(c.ConfigFile, c.Logger)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+
+		c.Logger.Debugf("Cannot read config file: %q", err)
+	}
+
+	opts, err := config.schedulerOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sh := core.NewSchedulerWithOptions(c.Logger, opts)
+	config.buildSchedulerMiddlewares(sh)
+
+	jobs := configJobs(config, c.ConfigFile)
+	if !c.DisableDocker {
+		labels, err := c.readLabels()
+		if err != nil {
+			c.Logger.Warningf("Cannot read the jobs of the Docker labels: %s", err)
+		}
+
+		if len(labels) > 0 {
+			lc := NewConfig(c.Logger)
+			if err := lc.buildFromDockerLabels(labels); err != nil {
+				return nil, nil, err
+			}
+
+			jobs = append(jobs, configJobs(lc, sourceLabels)...)
+		}
+	}
+
+	global := middlewareNames(sh.Middlewares())
+	for _, j := range jobs {
+		j.Middlewares = append(middlewareNames(j.job.Middlewares()), global...)
+		j.Middlewares = uniqueStrings(j.Middlewares)
+	}
+
+	sort.SliceStable(jobs, func(i, k int) bool {
+		return jobs[i].Name < jobs[k].Name
+	})
+
+	return jobs, sh, nil
+}
+
+func (c *jobLoader) readLabels() (map[string]map[string]string, error) {
+	if c.labels != nil {
+		return c.labels()
+	}
+
+	client, err := core.NewDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	labels, err := getDockerLabels(client, c.LabelOptions)
+	if errors.Is(err, ErrNoContainerWithChadburnEnabled) {
+		return nil, nil
+	}
+
+	return labels, err
+}
+
+func (c *jobLoader) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// configJobs returns the jobs of a configuration with their middlewares
+func configJobs(c *Config, source string) []*jobInfo {
+	var jobs []*jobInfo
+	add := func(name string, j interface {
+		core.Job
+		GetType() string
+		buildMiddlewares()
+	}, target string) *jobInfo {
+		j.buildMiddlewares()
+		info := &jobInfo{
+			Name:     name,
+			Type:     j.GetType(),
+			Schedule: j.GetSchedule(),
+			Timezone: j.GetTimezone(),
+			Target:   target,
+			Source:   source,
+			job:      j,
+		}
+
+		// the jobs of a directory or of an included file show their own file
+		if file := c.jobSource(name); file != "" {
+			info.Source = file
+		}
+
+		if core.IsTriggered(j) {
+			info.Schedule = "after " + strings.Join(j.GetDependencies().DependsOn, ",")
+		}
+
+		jobs = append(jobs, info)
+		return info
+	}
+
+	for name, j := range c.ExecJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		target := j.Container
+		if j.ComposeService != "" {
+			target = "service " + j.ComposeService
+			if j.ComposeProject != "" {
+				target = "service " + j.ComposeProject + "/" + j.ComposeService
+			}
+		}
+
+		add(name, j, target)
+	}
+
+	for name, j := range c.RunJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		target := j.Image
+		if target == "" {
+			target = j.Container
+		}
+
+		add(name, j, target)
+	}
+
+	for name, j := range c.ServiceJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		add(name, j, j.Image)
+	}
+
+	for name, j := range c.LocalJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		add(name, j, j.Dir)
+	}
+
+	for name, j := range c.LifecycleJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		add(name, j, j.Container).Schedule = "on container " + string(j.EventType)
+	}
+
+	return jobs
+}
+
+// middlewareNames returns the names of the middlewares, eg. "slack"
+func middlewareNames(ms []core.Middleware) []string {
+	names := []string{}
+	for _, m := range ms {
+		t := reflect.TypeOf(m)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		names = append(names, strings.ToLower(t.Name()))
+	}
+
+	return names
+}
+
+func uniqueStrings(s []string) []string {
+	seen := make(map[string]bool, len(s))
+	unique := []string{}
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
+
+// ListCommand prints the jobs of the configuration file and of the labels of
+// the running containers
+type ListCommand struct {
+	jobLoader
+}
+
+// Execute runs the list command
+func (c *ListCommand) Execute(args []string) error {
+	jobs, _, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	if c.JSON {
+		if jobs == nil {
+			jobs = []*jobInfo{}
+		}
+
+		return c.printJSON(jobs)
+	}
+
+	w := tabwriter.NewWriter(c.output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSCHEDULE\tTARGET\tSOURCE\tMIDDLEWARES")
+	for _, j := range jobs {
+		schedule := j.Schedule
+		if j.Timezone != "" {
+			schedule += " (" + j.Timezone + ")"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			j.Name, j.Type, schedule, j.Target, j.Source, strings.Join(j.Middlewares, ","),
+		)
+	}
+
+	return w.Flush()
+}
+
+// NextCommand prints the next times the jobs are scheduled to run
+type NextCommand struct {
+	jobLoader
+	Count int    `long:"count" description:"number of times to show per job" default:"1"`
+	Since string `long:"since" description:"compute the times after this date, eg.: 2025-01-31 or 2025-01-31T08:00:00Z, default now"`
+	Job   string `long:"job" description:"only show the times of this job"`
+
+	now func() time.Time
+}
+
+// nextRun is a time a job is scheduled to run
+type nextRun struct {
+	Time     time.Time `json:"time"`
+	Job      string    `json:"job"`
+	Type     string    `json:"type"`
+	Schedule string    `json:"schedule"`
+}
+
+// Execute runs the next command
+func (c *NextCommand) Execute(args []string) error {
+	jobs, sh, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	from, err := c.from(sh)
+	if err != nil {
+		return err
+	}
+
+	runs := []*nextRun{}
+	for _, j := range jobs {
+		if c.Job != "" && j.Name != c.Job {
+			continue
+		}
+
+		if j.Type == jobLifecycle {
+			continue
+		}
+
+		times, err := sh.NextTimes(j.job, from, c.Count)
+		if err != nil {
+			return fmt.Errorf("job %q: invalid schedule %q: %s", j.Name, j.Schedule, err)
+		}
+
+		for _, t := range times {
+			runs = append(runs, &nextRun{Time: t, Job: j.Name, Type: j.Type, Schedule: j.Schedule})
+		}
+	}
+
+	sort.SliceStable(runs, func(i, k int) bool {
+		return runs[i].Time.Before(runs[k].Time)
+	})
+
+	if c.JSON {
+		return c.printJSON(runs)
+	}
+
+	w := tabwriter.NewWriter(c.output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tIN\tJOB\tTYPE\tSCHEDULE")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			r.Time.Format("2006-01-02 15:04:05 MST"), r.Time.Sub(from).Round(time.Second), r.Job, r.Type, r.Schedule,
+		)
+	}
+
+	return w.Flush()
+}
+
+// sinceLayouts are the formats accepted by --since, the ones without time
+// zone are in the time zone of the scheduler
+var sinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func (c *NextCommand) from(sh *core.Scheduler) (time.Time, error) {
+	if c.Since == "" {
+		if c.now != nil {
+			return c.now(), nil
+		}
+
+		return time.Now(), nil
+	}
+
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, c.Since, sh.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q, must be a date as 2025-01-31 or 2025-01-31T08:00:00Z", c.Since)
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteList struct {
+	config string
+	labels map[string]map[string]string
+}
+
+var _ = Suite(&SuiteList{})
+
+func (s *SuiteList) SetUpTest(c *C) {
+	s.config = filepath.Join(c.MkDir(), "chadburn.conf")
+	err := ioutil.WriteFile(s.config, []byte(`
+		[global]
+		timezone = UTC
+		slack-webhook = https://example.com/hook
+
+		[job-local "backup"]
+		schedule = 0 3 * * *
+		command = true
+		retry-count = 2
+
+		[job-local "report"]
+		depends-on = backup
+		command = true
+
+		[job-exec "dump"]
+		schedule = 0 */6 * * *
+		container = db
+		command = pg_dump
+	`), 0644)
+
+	c.Assert(err, IsNil)
+
+	s.labels = map[string]map[string]string{
+		"web": {
+			requiredLabel: "true",
+			labelPrefix + "." + jobExec + ".cache.schedule": "30 * * * *",
+			labelPrefix + "." + jobExec + ".cache.command":  "clear-cache",
+		},
+	}
+}
+
+func (s *SuiteList) loader() (jobLoader, *bytes.Buffer) {
+	b := bytes.NewBuffer(nil)
+	return jobLoader{
+		ConfigFile: s.config,
+		Logger:     &TestLogger{},
+		output:     b,
+		labels: func() (map[string]map[string]string, error) {
+			return s.labels, nil
+		},
+	}, b
+}
+
+func (s *SuiteList) TestList(c *C) {
+	loader, b := s.loader()
+	cmd := &ListCommand{jobLoader: loader}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	c.Assert(lines, HasLen, 5)
+	c.Assert(lines[0], Matches, "NAME +TYPE +SCHEDULE +TARGET +SOURCE +MIDDLEWARES")
+	c.Assert(lines[1], Matches, "backup +job-local +0 3 \\* \\* \\* +.*chadburn.conf +retry,slack")
+	c.Assert(lines[2], Matches, "cache +job-exec +30 \\* \\* \\* \\* +web +docker-labels +slack")
+	c.Assert(lines[3], Matches, "dump +job-exec +0 \\*/6 \\* \\* \\* +db .*")
+	c.Assert(lines[4], Matches, "report +job-local +after backup .*")
+}
+
+func (s *SuiteList) TestListJSON(c *C) {
+	loader, b := s.loader()
+	loader.JSON = true
+	loader.DisableDocker = true
+
+	cmd := &ListCommand{jobLoader: loader}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	var jobs []*jobInfo
+	c.Assert(json.Unmarshal(b.Bytes(), &jobs), IsNil)
+	c.Assert(jobs, HasLen, 3)
+	c.Assert(jobs[0].Name, Equals, "backup")
+	c.Assert(jobs[0].Type, Equals, jobLocal)
+	c.Assert(jobs[0].Source, Equals, s.config)
+	c.Assert(jobs[0].Middlewares, DeepEquals, []string{"retry", "slack"})
+}
+
+func (s *SuiteList) TestNext(c *C) {
+	loader, b := s.loader()
+	loader.JSON = true
+
+	cmd := &NextCommand{jobLoader: loader, Count: 2, Since: "2025-01-31 02:00"}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	var runs []*nextRun
+	c.Assert(json.Unmarshal(b.Bytes(), &runs), IsNil)
+
+	var got []string
+	for _, r := range runs {
+		got = append(got, r.Time.UTC().Format("15:04")+" "+r.Job)
+	}
+
+	c.Assert(got, DeepEquals, []string{
+		"02:30 cache",
+		"03:00 backup",
+		"03:30 cache",
+		"06:00 dump",
+		"12:00 dump",
+		"03:00 backup",
+	})
+}
+
+func (s *SuiteList) TestNextJob(c *C) {
+	loader, b := s.loader()
+	cmd := &NextCommand{jobLoader: loader, Count: 1, Since: "2025-01-31T02:00:00Z", Job: "dump"}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[1], Matches, "2025-01-31 06:00:00 UTC +4h0m0s +dump +job-exec .*")
+}
+
+func (s *SuiteList) TestNextInvalidSince(c *C) {
+	loader, _ := s.loader()
+	cmd := &NextCommand{jobLoader: loader, Count: 1, Since: "tomorrow"}
+	c.Assert(cmd.Execute(nil), ErrorMatches, "invalid --since.*")
+}
+
+func (s *SuiteList) TestListIncludedSource(c *C) {
+	included := filepath.Join(filepath.Dir(s.config), "cleanup.conf")
+	c.Assert(ioutil.WriteFile(included, []byte(`
+		[job-run "cleanup"]
+		schedule = @daily
+		image = busybox
+	`), 0644), IsNil)
+
+	content, err := ioutil.ReadFile(s.config)
+	c.Assert(err, IsNil)
+	content = append([]byte("[global]\ninclude = cleanup.conf\n"), content...)
+	c.Assert(ioutil.WriteFile(s.config, content, 0644), IsNil)
+
+	loader, b := s.loader()
+	loader.JSON = true
+	loader.DisableDocker = true
+
+	cmd := &ListCommand{jobLoader: loader}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	var jobs []*jobInfo
+	c.Assert(json.Unmarshal(b.Bytes(), &jobs), IsNil)
+	c.Assert(jobs, HasLen, 4)
+	c.Assert(jobs[0].Name, Equals, "backup")
+	c.Assert(jobs[0].Source, Equals, s.config)
+	c.Assert(jobs[1].Name, Equals, "cleanup")
+	c.Assert(jobs[1].Source, Equals, included)
+}
+
+func (s *SuiteList) TestListComposeService(c *C) {
+	s.labels["search"] = map[string]string{
+		requiredLabel: "true",
+		labelPrefix + "." + jobExec + ".reindex.schedule":        "@daily",
+		labelPrefix + "." + jobExec + ".reindex.command":         "reindex",
+		labelPrefix + "." + jobExec + ".reindex.compose-project": "shop",
+		labelPrefix + "." + jobExec + ".reindex.compose-service": "search",
+	}
+
+	loader, b := s.loader()
+	loader.JSON = true
+
+	cmd := &ListCommand{jobLoader: loader}
+	c.Assert(cmd.Execute(nil), IsNil)
+
+	var jobs []*jobInfo
+	c.Assert(json.Unmarshal(b.Bytes(), &jobs), IsNil)
+	c.Assert(jobs, HasLen, 5)
+	c.Assert(jobs[3].Name, Equals, "reindex")
+	c.Assert(jobs[3].Target, Equals, "service shop/search")
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/op/go-logging"
+)
+
+// JSONLogger writes every line as a JSON object, with the time, the level,
+// the message and the fields of the line, to be indexed without parsing
+type JSONLogger struct {
+	level  logging.Level
+	fields core.Fields
+	out    *jsonOutput
+}
+
+type jsonOutput struct {
+	sync.Mutex
+	w io.Writer
+}
+
+// NewJSONLogger returns a JSONLogger writing the lines up to the given level
+func NewJSONLogger(w io.Writer, level logging.Level) *JSONLogger {
+	return &JSONLogger{level: level, out: &jsonOutput{w: w}}
+}
+
+// WithFields implements core.FieldLogger
+func (l *JSONLogger) WithFields(f core.Fields) core.Logger {
+	fields := make(core.Fields, len(l.fields)+len(f))
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+
+	for k, v := range f {
+		fields[k] = v
+	}
+
+	return &JSONLogger{level: l.level, fields: fields, out: l.out}
+}
+
+func (l *JSONLogger) Criticalf(format string, args ...interface{}) {
+	l.log(logging.CRITICAL, format, args)
+}
+
+func (l *JSONLogger) Debugf(format string, args ...interface{}) {
+	l.log(logging.DEBUG, format, args)
+}
+
+func (l *JSONLogger) Errorf(format string, args ...interface{}) {
+	l.log(logging.ERROR, format, args)
+}
+
+func (l *JSONLogger) Noticef(format string, args ...interface{}) {
+	l.log(logging.NOTICE, format, args)
+}
+
+func (l *JSONLogger) Warningf(format string, args ...interface{}) {
+	l.log(logging.WARNING, format, args)
+}
+
+func (l *JSONLogger) log(level logging.Level, format string, args []interface{}) {
+	if level > l.level {
+		return
+	}
+
+	line := make(map[string]interface{}, len(l.fields)+3)
+	for k, v := range l.fields {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+
+		line[k] = v
+	}
+
+	line["time"] = time.Now().Format(time.RFC3339Nano)
+	line["level"] = strings.ToLower(level.String())
+	line["message"] = fmt.Sprintf(format, args...)
+
+	b, err := json.Marshal(line)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			"time":    line["time"],
+			"level":   line["level"],
+			"message": line["message"],
+			"error":   fmt.Sprintf("cannot encode the fields: %s", err),
+		})
+	}
+
+	l.out.Lock()
+	defer l.out.Unlock()
+	l.out.w.Write(append(b, '\n'))
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/op/go-logging"
+	. "gopkg.in/check.v1"
+)
+
+type SuiteLogger struct{}
+
+var _ = Suite(&SuiteLogger{})
+
+func (s *SuiteLogger) TestJSONLogger(c *C) {
+	b := bytes.NewBuffer(nil)
+	l := NewJSONLogger(b, logging.NOTICE)
+
+	l.Noticef("foo %d", 42)
+	l.Debugf("hidden")
+	l.WithFields(core.Fields{"job": "backup", "error": errors.New("bar")}).Errorf("failed")
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+
+	var line map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(lines[0]), &line), IsNil)
+	c.Assert(line["level"], Equals, "notice")
+	c.Assert(line["message"], Equals, "foo 42")
+	c.Assert(line["time"], Not(Equals), "")
+
+	line = nil
+	c.Assert(json.Unmarshal([]byte(lines[1]), &line), IsNil)
+	c.Assert(line["level"], Equals, "error")
+	c.Assert(line["job"], Equals, "backup")
+	c.Assert(line["error"], Equals, "bar")
+}
+
+func (s *SuiteLogger) TestJSONLoggerJobEvents(c *C) {
+	b := bytes.NewBuffer(nil)
+	sh := core.NewScheduler(NewJSONLogger(b, logging.INFO))
+
+	job := &LocalJobConfig{}
+	job.Name = "backup"
+	job.Command = "false"
+
+	ctx := core.NewContext(sh, job, core.NewExecution())
+	ctx.Start()
+	ctx.Stop(errors.New("foo"))
+	ctx.LogEvent(core.EventFinished, "finished", core.Fields{"error": "foo"})
+
+	var line map[string]interface{}
+	c.Assert(json.Unmarshal(b.Bytes(), &line), IsNil)
+	c.Assert(line["level"], Equals, "error")
+	c.Assert(line["job"], Equals, "backup")
+	c.Assert(line["job_type"], Equals, jobLocal)
+	c.Assert(line["execution"], Equals, ctx.Execution.ID)
+	c.Assert(line["event"], Equals, core.EventFinished)
+	c.Assert(line["error"], Equals, "foo")
+}
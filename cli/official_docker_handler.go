@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"errors"
 	"io"
 	"strings"
 	"time"
@@ -16,12 +15,13 @@ type OfficialDockerHandler struct {
 	notifier      dockerLabelsUpdate
 	logger        core.Logger
 	lifecycleJobs map[string]*LifecycleJobConfig // Map of lifecycle jobs
+	labels        *labelsWatcher
 	ctx           context.Context
 	cancel        context.CancelFunc
 }
 
 // NewOfficialDockerHandler creates a new Docker handler using the official Docker client
-func NewOfficialDockerHandler(notifier dockerLabelsUpdate, logger core.Logger) (*OfficialDockerHandler, error) {
+func NewOfficialDockerHandler(notifier dockerLabelsUpdate, logger core.Logger, poll time.Duration, labels LabelOptions) (*OfficialDockerHandler, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client, err := core.NewDockerClient()
@@ -35,11 +35,12 @@ func NewOfficialDockerHandler(notifier dockerLabelsUpdate, logger core.Logger) (
 		notifier:      notifier,
 		logger:        logger,
 		lifecycleJobs: make(map[string]*LifecycleJobConfig),
+		labels:        newLabelsWatcher(client, notifier, logger, poll, labels),
 		ctx:           ctx,
 		cancel:        cancel,
 	}
 
-	go handler.watch()
+	go handler.labels.watch(ctx)
 	go handler.watchEvents()
 
 	return handler, nil
@@ -61,45 +62,6 @@ func (c *OfficialDockerHandler) Close() error {
 	return c.dockerClient.Close()
 }
 
-// watch polls for changes in Docker containers
-func (c *OfficialDockerHandler) watch() {
-	// Poll for changes
-	tick := time.Tick(10000 * time.Millisecond)
-
-	// Add backoff for connection issues
-	connectionBackoff := 100 * time.Millisecond
-	maxConnectionBackoff := 5 * time.Second
-
-	for {
-		select {
-		case <-tick:
-			labels, err := c.GetDockerLabels()
-			// Do not print or care if there is no container up right now
-			if err != nil && !errors.Is(err, ErrNoContainerWithChadburnEnabled) {
-				c.logger.Debugf("%v", err)
-
-				// Check if it's a connection error
-				if strings.Contains(err.Error(), "Cannot connect to the Docker daemon") {
-					// Apply exponential backoff
-					if connectionBackoff < maxConnectionBackoff {
-						connectionBackoff *= 2
-					}
-					c.logger.Noticef("Docker daemon connection issue. Waiting %v before next attempt...", connectionBackoff)
-					time.Sleep(connectionBackoff)
-					continue
-				}
-			} else {
-				// Reset backoff on success
-				connectionBackoff = 100 * time.Millisecond
-			}
-
-			c.notifier.dockerLabelsUpdate(labels)
-		case <-c.ctx.Done():
-			return
-		}
-	}
-}
-
 // watchEvents watches Docker events
 func (c *OfficialDockerHandler) watchEvents() {
 	// Add a backoff mechanism to prevent rapid restarts
@@ -114,6 +76,7 @@ func (c *OfficialDockerHandler) watchEvents() {
 		c.dockerClient.WatchEvents(c.ctx, eventCh, errCh)
 
 		c.logger.Noticef("Started watching Docker events")
+		c.labels.schedule()
 
 		// Process events
 		shouldReconnect := false
@@ -152,6 +115,8 @@ func (c *OfficialDockerHandler) watchEvents() {
 
 				// Process container events
 				if event.Type == "container" {
+					c.labels.event(event.Action)
+
 					// Get container name from attributes
 					containerName, ok := event.Attributes["name"]
 					if !ok {
@@ -203,52 +168,5 @@ func (c *OfficialDockerHandler) processLifecycleEvent(containerName, containerID
 
 // GetDockerLabels gets Docker labels from containers
 func (c *OfficialDockerHandler) GetDockerLabels() (map[string]map[string]string, error) {
-	// First, get containers with the required label
-	containers, err := c.dockerClient.ListContainers(map[string][]string{
-		"label": {requiredLabelFilter},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Also get containers with job-run labels
-	jobRunContainers, err := c.dockerClient.ListContainers(map[string][]string{
-		"label": {labelPrefix + "." + jobRun},
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Combine the two lists, avoiding duplicates
-	contMap := make(map[string]core.Container)
-	for _, cont := range containers {
-		contMap[cont.Name] = cont
-	}
-	for _, cont := range jobRunContainers {
-		contMap[cont.Name] = cont
-	}
-
-	if len(contMap) == 0 {
-		return nil, ErrNoContainerWithChadburnEnabled
-	}
-
-	var labels = make(map[string]map[string]string)
-
-	for name, c := range contMap {
-		if len(c.Labels) > 0 {
-			containerLabels := make(map[string]string)
-			for k, v := range c.Labels {
-				// only include relevant labels
-				if strings.HasPrefix(k, labelPrefix) {
-					containerLabels[k] = v
-				}
-			}
-
-			if len(containerLabels) > 0 {
-				labels[name] = containerLabels
-			}
-		}
-	}
-
-	return labels, nil
+	return getDockerLabels(c.dockerClient, c.labels.options)
 }
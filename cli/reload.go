@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	defaults "github.com/mcuadros/go-defaults"
+	gcfg "gopkg.in/gcfg.v1"
+)
+
+// Reload reads again the configuration file and applies the changes of the
+// jobs defined on it: new jobs are added to the scheduler, removed jobs are
+// deregistered and modified jobs are replaced. The running executions are not
+// interrupted. If the file is not valid, the running configuration is kept.
+func (c *Config) Reload(filename string) error {
+	parsed, err := BuildFromFile(filename, c.logger)
+	if err != nil {
+		if gcfg.FatalOnly(err) != nil {
+			return fmt.Errorf("invalid configuration file %q: %s", filename, err)
+		}
+
+		c.logger.Warningf("Configuration file %q: %s", filename, err)
+	}
+
+	if err := parsed.validate(c.sh); err != nil {
+		return fmt.Errorf("invalid configuration file %q: %s", filename, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sources, c.paths = parsed.sources, parsed.paths
+	if !reflect.DeepEqual(c.Global, parsed.Global) {
+		c.logger.Warningf("Changes to the [global] section require a restart to be applied")
+	}
+
+	if !reflect.DeepEqual(c.Webhooks, parsed.Webhooks) {
+		c.logger.Warningf("Changes to the [webhook] sections require a restart to be applied")
+	}
+
+	if !c.disableDocker {
+		c.reloadExecJobs(parsed)
+		c.reloadRunJobs(parsed)
+		c.reloadServiceJobs(parsed)
+		c.reloadLifecycleJobs(parsed)
+	}
+
+	c.reloadLocalJobs(parsed)
+
+	c.logger.Noticef("Configuration file %q reloaded", filename)
+	return nil
+}
+
+// validate sets the defaults and the names of the jobs, and returns an error
+// if any of them cannot be scheduled by the given scheduler
+func (c *Config) validate(sh *core.Scheduler) error {
+	var jobs []core.Job
+	for name, j := range c.ExecJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.RunJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.ServiceJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.LocalJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.LifecycleJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+	}
+
+	for _, j := range jobs {
+		if err := sh.ValidateJob(j); err != nil {
+			return fmt.Errorf("job %s: %s", c.describeJob(j.GetName()), err)
+		}
+	}
+
+	return core.CheckDependencies(jobs)
+}
+
+func (c *Config) reloadExecJobs(parsed *Config) {
+	for name, j := range c.ExecJobs {
+		if _, ok := parsed.ExecJobs[name]; !ok && !j.FromDockerLabel {
+			c.sh.RemoveJob(j)
+			delete(c.ExecJobs, name)
+		}
+	}
+
+	for name, j := range parsed.ExecJobs {
+		old, ok := c.ExecJobs[name]
+		if ok && old.FromDockerLabel {
+			c.logger.Warningf("Job %q is already defined by Docker labels, ignoring %s", name, parsed.jobSource(name))
+			continue
+		}
+
+		if ok && configHash(old) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		j.Client = client
+		j.buildMiddlewares()
+		c.replaceJob(old, j, ok)
+		c.ExecJobs[name] = j
+	}
+}
+
+func (c *Config) reloadRunJobs(parsed *Config) {
+	for name, j := range c.RunJobs {
+		if _, ok := parsed.RunJobs[name]; !ok && !j.FromDockerLabel {
+			c.sh.RemoveJob(j)
+			delete(c.RunJobs, name)
+		}
+	}
+
+	for name, j := range parsed.RunJobs {
+		old, ok := c.RunJobs[name]
+		if ok && old.FromDockerLabel {
+			c.logger.Warningf("Job %q is already defined by Docker labels, ignoring %s", name, parsed.jobSource(name))
+			continue
+		}
+
+		if ok && configHash(old) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		j.Client = client
+		j.buildMiddlewares()
+		c.replaceJob(old, j, ok)
+		c.RunJobs[name] = j
+	}
+}
+
+func (c *Config) reloadServiceJobs(parsed *Config) {
+	for name, j := range c.ServiceJobs {
+		if _, ok := parsed.ServiceJobs[name]; !ok && !j.FromDockerLabel {
+			c.sh.RemoveJob(j)
+			delete(c.ServiceJobs, name)
+		}
+	}
+
+	for name, j := range parsed.ServiceJobs {
+		old, ok := c.ServiceJobs[name]
+		if ok && old.FromDockerLabel {
+			c.logger.Warningf("Job %q is already defined by Docker labels, ignoring %s", name, parsed.jobSource(name))
+			continue
+		}
+
+		if ok && configHash(old) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		j.Client = client
+		j.buildMiddlewares()
+		c.replaceJob(old, j, ok)
+		c.ServiceJobs[name] = j
+	}
+}
+
+func (c *Config) reloadLocalJobs(parsed *Config) {
+	for name, j := range c.LocalJobs {
+		if _, ok := parsed.LocalJobs[name]; !ok && !j.FromDockerLabel {
+			c.sh.RemoveJob(j)
+			delete(c.LocalJobs, name)
+		}
+	}
+
+	for name, j := range parsed.LocalJobs {
+		old, ok := c.LocalJobs[name]
+		if ok && old.FromDockerLabel {
+			c.logger.Warningf("Job %q is already defined by Docker labels, ignoring %s", name, parsed.jobSource(name))
+			continue
+		}
+
+		if ok && configHash(old) == configHash(j) {
+			continue
+		}
+
+		j.buildMiddlewares()
+		c.replaceJob(old, j, ok)
+		c.LocalJobs[name] = j
+	}
+}
+
+// reloadLifecycleJobs updates the lifecycle jobs in place, the map is shared
+// with the Docker handler that runs them
+func (c *Config) reloadLifecycleJobs(parsed *Config) {
+	for name, j := range c.LifecycleJobs {
+		if _, ok := parsed.LifecycleJobs[name]; !ok && !j.FromDockerLabel {
+			delete(c.LifecycleJobs, name)
+		}
+	}
+
+	for name, j := range parsed.LifecycleJobs {
+		old, ok := c.LifecycleJobs[name]
+		if ok && old.FromDockerLabel {
+			c.logger.Warningf("Job %q is already defined by Docker labels, ignoring %s", name, parsed.jobSource(name))
+			continue
+		}
+
+		if ok && configHash(old) == configHash(j) {
+			continue
+		}
+
+		client, err := c.internalDockerClient()
+		if err != nil {
+			c.logger.Errorf("Failed to create Docker handler: %v", err)
+			continue
+		}
+
+		j.Client = client
+		j.buildMiddlewares()
+		c.LifecycleJobs[name] = j
+	}
+}
+
+// removeJob deregisters a job and removes it from the configuration, so the
+// next reload or refresh of the Docker labels adds it back if it is still
+// defined
+func (c *Config) removeJob(j core.Job) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	name := j.GetName()
+	if job, ok := c.ExecJobs[name]; ok && core.Job(job) == j {
+		delete(c.ExecJobs, name)
+	}
+
+	if job, ok := c.RunJobs[name]; ok && core.Job(job) == j {
+		delete(c.RunJobs, name)
+	}
+
+	if job, ok := c.ServiceJobs[name]; ok && core.Job(job) == j {
+		delete(c.ServiceJobs, name)
+	}
+
+	if job, ok := c.LocalJobs[name]; ok && core.Job(job) == j {
+		delete(c.LocalJobs, name)
+	}
+
+	return c.sh.RemoveJob(j)
+}
+
+// replaceJob registers the new version of a job, deregistering the old one if
+// exists. Executions of the old version already running are not interrupted.
+func (c *Config) replaceJob(old, j core.Job, exists bool) {
+	var err error
+	if exists {
+		err = c.sh.ReplaceJob(old, j)
+	} else {
+		err = c.sh.AddJob(j)
+	}
+
+	if err != nil {
+		c.logger.Errorf("Cannot register job %s: %s", c.describeJob(j.GetName()), err)
+	}
+}
+
+// configHash returns a hash of a job configuration, including the options of
+// its middlewares, used to detect changes
+func configHash(job interface{}) string {
+	v := reflect.ValueOf(job).Elem()
+
+	var hash string
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanInterface() {
+			continue
+		}
+
+		if h, ok := f.Addr().Interface().(interface{ Hash() string }); ok {
+			hash += h.Hash()
+			continue
+		}
+
+		hash += fmt.Sprintf("%+v;", f.Interface())
+	}
+
+	return hash
+}
+
+// watchConfigFile calls fn every time one of the configuration files is
+// modified, added or removed
+func watchConfigFile(paths func() []string, interval time.Duration, done <-chan struct{}, fn func()) {
+	last := configVersion(paths())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			version := configVersion(paths())
+			if version == last {
+				continue
+			}
+
+			last = version
+			fn()
+		case <-done:
+			return
+		}
+	}
+}
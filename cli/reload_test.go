@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteReload struct {
+	dir      string
+	filename string
+	config   *Config
+}
+
+var _ = Suite(&SuiteReload{})
+
+func (s *SuiteReload) SetUpTest(c *C) {
+	s.dir = c.MkDir()
+	s.filename = filepath.Join(s.dir, "chadburn.conf")
+	s.write(c, `
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+
+		[job-local "bar"]
+		schedule = @every 10s
+		command = echo bar
+	`)
+
+	var err error
+	s.config, err = BuildFromFile(s.filename, &TestLogger{})
+	c.Assert(err, IsNil)
+	c.Assert(s.config.InitializeApp(true), IsNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 2)
+}
+
+func (s *SuiteReload) write(c *C, content string) {
+	c.Assert(ioutil.WriteFile(s.filename, []byte(content), 0644), IsNil)
+}
+
+func (s *SuiteReload) TestReload(c *C) {
+	foo := s.config.sh.GetJob("foo")
+	bar := s.config.sh.GetJob("bar")
+
+	s.write(c, `
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+
+		[job-local "bar"]
+		schedule = @every 20s
+		command = echo bar
+
+		[job-local "qux"]
+		schedule = @every 10s
+		command = echo qux
+	`)
+
+	c.Assert(s.config.Reload(s.filename), IsNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 3)
+
+	// unchanged jobs are kept as they are
+	c.Assert(s.config.sh.GetJob("foo"), Equals, foo)
+	c.Assert(s.config.sh.GetJob("bar"), Not(Equals), bar)
+	c.Assert(s.config.sh.GetJob("bar").GetSchedule(), Equals, "@every 20s")
+	c.Assert(s.config.sh.GetJob("qux"), NotNil)
+
+	s.write(c, `
+		[job-local "qux"]
+		schedule = @every 10s
+		command = echo qux
+	`)
+
+	c.Assert(s.config.Reload(s.filename), IsNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 1)
+	c.Assert(s.config.LocalJobs, HasLen, 1)
+	c.Assert(s.config.sh.GetJob("qux"), NotNil)
+}
+
+func (s *SuiteReload) TestReloadMiddlewareChange(c *C) {
+	foo := s.config.sh.GetJob("foo")
+
+	s.write(c, `
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+		retry-count = 3
+
+		[job-local "bar"]
+		schedule = @every 10s
+		command = echo bar
+	`)
+
+	c.Assert(s.config.Reload(s.filename), IsNil)
+	c.Assert(s.config.sh.GetJob("foo"), Not(Equals), foo)
+	c.Assert(s.config.sh.GetJob("foo").Middlewares(), HasLen, 1)
+}
+
+func (s *SuiteReload) TestReloadInvalid(c *C) {
+	for _, content := range []string{
+		`[job-local "foo"`,
+		`
+		[job-local "foo"]
+		schedule = every day
+		`,
+		`
+		[job-local "foo"]
+		schedule = @daily
+		on-success = foo
+		`,
+	} {
+		s.write(c, content)
+		c.Assert(s.config.Reload(s.filename), NotNil)
+		c.Assert(s.config.sh.GetJobs(), HasLen, 2)
+	}
+
+	c.Assert(os.Remove(s.filename), IsNil)
+	c.Assert(s.config.Reload(s.filename), NotNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 2)
+}
+
+func (s *SuiteReload) TestConfigHash(c *C) {
+	a := &LocalJobConfig{}
+	a.Schedule = "@daily"
+	b := &LocalJobConfig{}
+	b.Schedule = "@daily"
+	c.Assert(configHash(a), Equals, configHash(b))
+
+	b.SlackWebhook = "http://example.com"
+	c.Assert(configHash(a), Not(Equals), configHash(b))
+
+	a.SlackWebhook = "http://example.com"
+	a.Environment = []string{"FOO=bar"}
+	c.Assert(configHash(a), Not(Equals), configHash(b))
+}
+
+func (s *SuiteReload) TestWatchConfigFile(c *C) {
+	done := make(chan struct{})
+	defer close(done)
+
+	changed := make(chan bool, 1)
+	go watchConfigFile(s.config.watchedPaths, 10*time.Millisecond, done, func() {
+		changed <- true
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	s.write(c, `
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo changed
+	`)
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		c.Fatal("change not detected")
+	}
+}
+
+func (s *SuiteReload) TestReloadInclude(c *C) {
+	s.write(c, `
+		[global]
+		include = conf.d/*.conf
+
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+	`)
+
+	included := filepath.Join(s.dir, "conf.d", "qux.conf")
+	c.Assert(os.Mkdir(filepath.Dir(included), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(included, []byte(`
+		[job-local "qux"]
+		schedule = @every 10s
+		command = echo qux
+	`), 0644), IsNil)
+
+	c.Assert(s.config.Reload(s.filename), IsNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 2)
+	c.Assert(s.config.sh.GetJob("qux"), NotNil)
+	c.Assert(s.config.jobSource("qux"), Equals, included)
+
+	// a job cannot be defined in two files
+	c.Assert(ioutil.WriteFile(included, []byte(`
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+	`), 0644), IsNil)
+
+	c.Assert(s.config.Reload(s.filename), ErrorMatches, `.*job "foo" defined in both .*`)
+	c.Assert(s.config.sh.GetJob("qux"), NotNil)
+}
+
+func (s *SuiteReload) TestWatchIncludedFiles(c *C) {
+	s.write(c, `
+		[global]
+		include = conf.d/*.conf
+	`)
+
+	c.Assert(os.Mkdir(filepath.Join(s.dir, "conf.d"), 0755), IsNil)
+	c.Assert(s.config.Reload(s.filename), IsNil)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	changed := make(chan bool, 1)
+	go watchConfigFile(s.config.watchedPaths, 10*time.Millisecond, done, func() {
+		changed <- true
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	err := ioutil.WriteFile(filepath.Join(s.dir, "conf.d", "foo.conf"), []byte(`
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+	`), 0644)
+	c.Assert(err, IsNil)
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		c.Fatal("new file not detected")
+	}
+}
+
+func (s *SuiteReload) TestReloadKeepsLabelJobs(c *C) {
+	s.config.dockerLabelsUpdate(map[string]map[string]string{
+		"chadburn": {
+			requiredLabel: "true",
+			serviceLabel:  "true",
+			labelPrefix + "." + jobLocal + ".cleanup.schedule": "@every 10s",
+			labelPrefix + "." + jobLocal + ".cleanup.command":  "echo cleanup",
+		},
+	})
+
+	cleanup := s.config.sh.GetJob("cleanup")
+	c.Assert(cleanup, NotNil)
+
+	s.write(c, `
+		[job-local "foo"]
+		schedule = @every 10s
+		command = echo foo
+	`)
+
+	c.Assert(s.config.Reload(s.filename), IsNil)
+	c.Assert(s.config.sh.GetJobs(), HasLen, 2)
+	c.Assert(s.config.sh.GetJob("cleanup"), Equals, cleanup)
+	c.Assert(s.config.sh.GetJob("bar"), IsNil)
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+// RunCommand runs a job once, in the foreground, with the same middlewares
+// as the daemon
+type RunCommand struct {
+	ConfigFile   string `long:"config" description:"configuration file or directory" default:"/etc/chadburn.conf"`
+	DockerLabels bool   `long:"docker-labels" description:"Also read the jobs from the labels of the running containers"`
+	LabelOptions
+	Args struct {
+		Job string `positional-arg-name:"job" description:"name of the job to run"`
+	} `positional-args:"yes" required:"yes"`
+	Logger core.Logger
+
+	// stdout and stderr receive the output of the job, os.Stdout and
+	// os.Stderr if nil
+	stdout, stderr io.Writer
+	client         core.DockerClient
+}
+
+// Execute runs the job, the error returned has the exit code of the command
+// if it failed
+func (c *RunCommand) Execute(args []string) error {
+	config, err := BuildFromFile(c.ConfigFile, c.Logger)
+	if err != nil {
+		return err
+	}
+
+	if c.DockerLabels {
+		if err := c.readDockerLabels(config); err != nil {
+			return err
+		}
+	}
+
+	job, sh, err := config.buildJob(c.Args.Job, c.dockerClient)
+	if err != nil {
+		return err
+	}
+
+	e := core.NewExecution()
+	e.Stdout, e.Stderr = c.stdout, c.stderr
+	if e.Stdout == nil {
+		e.Stdout, e.Stderr = os.Stdout, os.Stderr
+	}
+
+	sh.ExecuteJob(job, e)
+
+	switch {
+	case e.Skipped():
+		c.Logger.Warningf("Job %q skipped: %s", c.Args.Job, e.Error())
+	case e.Failed() && e.ExitCode > 0:
+		return core.NewExitCodeError(e.ExitCode)
+	case e.Failed():
+		return e.Error()
+	}
+
+	return nil
+}
+
+func (c *RunCommand) readDockerLabels(config *Config) error {
+	client, err := c.dockerClient()
+	if err != nil {
+		return err
+	}
+
+	labels, err := getDockerLabels(client, c.LabelOptions)
+	if err != nil && !errors.Is(err, ErrNoContainerWithChadburnEnabled) {
+		return fmt.Errorf("cannot read the Docker labels: %s", err)
+	}
+
+	return config.buildFromDockerLabels(labels)
+}
+
+// dockerClient returns the Docker client, it is created on the first call
+func (c *RunCommand) dockerClient() (core.DockerClient, error) {
+	if c.client == nil {
+		client, err := core.NewDockerClient()
+		if err != nil {
+			return nil, err
+		}
+
+		c.client = client
+	}
+
+	return c.client, nil
+}
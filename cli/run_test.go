@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/PremoWeb/Chadburn/core"
+	. "gopkg.in/check.v1"
+)
+
+type SuiteRun struct {
+	config string
+}
+
+var _ = Suite(&SuiteRun{})
+
+func (s *SuiteRun) SetUpTest(c *C) {
+	dir := c.MkDir()
+	script := filepath.Join(dir, "broken.sh")
+	err := ioutil.WriteFile(script, []byte("#!/bin/sh\necho oops >&2\nexit 3\n"), 0755)
+	c.Assert(err, IsNil)
+
+	s.config = filepath.Join(dir, "chadburn.conf")
+	err = ioutil.WriteFile(s.config, []byte(`
+		[job-local "hello"]
+		schedule = @daily
+		command = echo hello
+
+		[job-local "broken"]
+		schedule = @daily
+		command = `+script+`
+
+		[job-exec "exec"]
+		schedule = @daily
+		container = foo
+		command = echo hello
+	`), 0644)
+
+	c.Assert(err, IsNil)
+}
+
+func (s *SuiteRun) command(job string) (*RunCommand, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	cmd := &RunCommand{ConfigFile: s.config, Logger: &TestLogger{}, stdout: stdout, stderr: stderr}
+	cmd.Args.Job = job
+
+	return cmd, stdout, stderr
+}
+
+func (s *SuiteRun) TestRun(c *C) {
+	cmd, stdout, _ := s.command("hello")
+	c.Assert(cmd.Execute(nil), IsNil)
+	c.Assert(stdout.String(), Equals, "hello\n")
+}
+
+func (s *SuiteRun) TestRunExitCode(c *C) {
+	cmd, _, stderr := s.command("broken")
+
+	err := cmd.Execute(nil)
+	code, ok := core.GetExitCode(err)
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, 3)
+	c.Assert(stderr.String(), Equals, "oops\n")
+}
+
+func (s *SuiteRun) TestRunUnknownJob(c *C) {
+	cmd, _, _ := s.command("foo")
+	c.Assert(cmd.Execute(nil), ErrorMatches, `unknown job "foo"`)
+}
+
+func (s *SuiteRun) TestRunMissingConfig(c *C) {
+	cmd, _, _ := s.command("hello")
+	cmd.ConfigFile = filepath.Join(c.MkDir(), "missing.conf")
+
+	err := cmd.Execute(nil)
+	c.Assert(errors.Is(err, os.ErrNotExist), Equals, true)
+}
+
+func (s *SuiteRun) TestBuildJobDocker(c *C) {
+	config, err := BuildFromFile(s.config, &TestLogger{})
+	c.Assert(err, IsNil)
+
+	client := &core.MockDockerClient{}
+	job, sh, err := config.buildJob("exec", func() (core.DockerClient, error) {
+		return client, nil
+	})
+
+	c.Assert(err, IsNil)
+	c.Assert(sh, NotNil)
+	c.Assert(job.GetName(), Equals, "exec")
+	c.Assert(job.(*ExecJobConfig).Client, Equals, client)
+
+	_, _, err = config.buildJob("exec", func() (core.DockerClient, error) {
+		return nil, errors.New("no socket")
+	})
+
+	c.Assert(err, ErrorMatches, `job "exec" \(.*chadburn.conf\) needs Docker: no socket`)
+}
@@ -1,23 +1,509 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/PremoWeb/Chadburn/core"
+	"github.com/PremoWeb/Chadburn/middlewares"
+
+	"github.com/gobs/args"
+	defaults "github.com/mcuadros/go-defaults"
+	gcfg "gopkg.in/gcfg.v1"
+	warnings "gopkg.in/warnings.v0"
 )
 
 // ValidateCommand validates the config file
 type ValidateCommand struct {
-	ConfigFile string `long:"config" description:"configuration file" default:"/etc/chadburn.conf"`
+	ConfigFile string `long:"config" description:"configuration file or directory" default:"/etc/chadburn.conf"`
+	Docker     bool   `long:"docker" description:"Also check that the containers and images used by the jobs exist"`
+	JSON       bool   `long:"json" description:"print the problems as JSON"`
 	Logger     core.Logger
+
+	output io.Writer
+	client core.DockerClient
+}
+
+// Problem is an issue found in the configuration file, the warnings do not
+// prevent the jobs from running
+type Problem struct {
+	// File is the file of the problem, set when it is not the one given to
+	// the command, eg. a file of a directory or an included file
+	File string `json:"file,omitempty"`
+	// Section is the section of the file, eg.: global or job-exec "backup"
+	Section string `json:"section,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (p Problem) String() string {
+	s := p.Message
+	if p.Key != "" {
+		s = p.Key + ": " + s
+	}
+
+	if p.Section != "" {
+		s = "[" + p.Section + "] " + s
+	}
+
+	if p.File != "" {
+		s = p.File + ": " + s
+	}
+
+	if p.Warning {
+		return "warning: " + s
+	}
+
+	return "error: " + s
 }
 
 // Execute runs the validation command
 func (c *ValidateCommand) Execute(args []string) error {
+	if c.output == nil {
+		c.output = os.Stdout
+	}
+
 	c.Logger.Debugf("Validating %q ... ", c.ConfigFile)
-	_, err := BuildFromFile(c.ConfigFile, c.Logger)
+	problems, err := c.validate()
 	if err != nil {
 		c.Logger.Errorf("ERROR")
 		return err
 	}
+
+	var errs int
+	for _, p := range problems {
+		if !p.Warning {
+			errs++
+		}
+	}
+
+	if c.JSON {
+		if err := c.printJSON(problems, errs == 0); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Fprintln(c.output, p)
+		}
+	}
+
+	if errs > 0 {
+		c.Logger.Errorf("ERROR")
+		return fmt.Errorf("%d error(s) found in %q", errs, c.ConfigFile)
+	}
+
 	c.Logger.Debugf("OK")
 	return nil
 }
+
+func (c *ValidateCommand) printJSON(problems []Problem, valid bool) error {
+	if problems == nil {
+		problems = []Problem{}
+	}
+
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		File     string    `json:"file"`
+		Valid    bool      `json:"valid"`
+		Problems []Problem `json:"problems"`
+	}{c.ConfigFile, valid, problems})
+}
+
+// validate returns the problems of the configuration file, an error is
+// returned only if the file or Docker cannot be read
+func (c *ValidateCommand) validate() ([]Problem, error) {
+	config, err := BuildFromFile(c.ConfigFile, c.Logger)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if fatal := gcfg.FatalOnly(err); fatal != nil {
+			p := Problem{Message: fatal.Error()}
+			var fileErr *fileError
+			if errors.As(fatal, &fileErr) {
+				p.File, p.Message = fileErr.file, fileErr.err.Error()
+			}
+
+			return []Problem{p}, nil
+		}
+	}
+
+	v := &validator{root: filepath.Clean(c.ConfigFile)}
+	for _, w := range warnings.WarningsOnly(err) {
+		v.unknownKey(w)
+	}
+
+	if c.Docker {
+		if c.client == nil {
+			if c.client, err = core.NewDockerClient(); err != nil {
+				return nil, fmt.Errorf("cannot connect to Docker: %s", err)
+			}
+
+			defer c.client.Close()
+		}
+
+		v.client = c.client
+	}
+
+	v.validate(config)
+	sort.SliceStable(v.problems, func(i, k int) bool {
+		return v.problems[i].Section < v.problems[k].Section
+	})
+
+	return v.problems, nil
+}
+
+// validator collects the problems of a configuration
+type validator struct {
+	client   core.DockerClient
+	problems []Problem
+	// root is the file or directory validated and file the one of the
+	// section being checked
+	root, file string
+}
+
+func (v *validator) add(section, key, format string, args ...interface{}) {
+	v.append(Problem{Section: section, Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(section, key, format string, args ...interface{}) {
+	v.append(Problem{Section: section, Key: key, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *validator) append(p Problem) {
+	if p.File == "" && v.file != v.root {
+		p.File = v.file
+	}
+
+	v.problems = append(v.problems, p)
+}
+
+// extraDataLocation matches the location of the data gcfg cannot store, eg.:
+// section "job-exec", subsection "foo", variable "bar"
+var extraDataLocation = regexp.MustCompile(`section "([^"]*)"(?:, subsection "([^"]*)")?(?:, variable "([^"]*)")?`)
+
+func (v *validator) unknownKey(err error) {
+	var file string
+	var fileErr *fileError
+	if errors.As(err, &fileErr) {
+		file, err = fileErr.file, fileErr.err
+	}
+
+	m := extraDataLocation.FindStringSubmatch(err.Error())
+	if m == nil {
+		v.append(Problem{File: file, Message: err.Error()})
+		return
+	}
+
+	section := m[1]
+	if m[2] != "" {
+		section = jobSection(m[1], m[2])
+	}
+
+	p := Problem{File: file, Section: section, Key: m[3], Message: "unknown key"}
+	if p.Key == "" {
+		p.Message = "unknown section"
+	}
+
+	for _, known := range v.problems {
+		if known == p {
+			return
+		}
+	}
+
+	v.append(p)
+}
+
+func jobSection(typ, name string) string {
+	return fmt.Sprintf("%s %q", typ, name)
+}
+
+func (v *validator) validate(c *Config) {
+	opts, err := c.schedulerOptions()
+	if err != nil {
+		v.add("global", "timezone", "%s", err)
+	}
+
+	sh := core.NewSchedulerWithOptions(c.logger, opts)
+	v.middlewares("global",
+		&c.Global.NotifyConfig, &c.Global.PingConfig, &c.Global.SlackConfig, &c.Global.MailConfig,
+		&c.Global.GotifyConfig, &c.Global.TeamsConfig, &c.Global.WebhookConfig,
+	)
+
+	if _, err := c.Global.HistoryConfig.MaxAge(); err != nil {
+		v.add("global", "history-max-age", "invalid history-max-age: %s", err)
+	}
+
+	var jobs []core.Job
+	for name, j := range c.ExecJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+
+		section := jobSection(jobExec, name)
+		v.file = c.jobSource(name)
+		v.job(sh, section, j)
+		if j.Container == "" && j.ComposeService == "" {
+			v.add(section, "container", "container or compose-service is required")
+		}
+
+		v.required(section, "command", j.Command)
+		switch j.Target {
+		case core.TargetOne, core.TargetAll, core.TargetRandom:
+		default:
+			v.add(section, "target", "invalid target %q, must be %q, %q or %q", j.Target, core.TargetOne, core.TargetAll, core.TargetRandom)
+		}
+
+		v.middlewares(section,
+			&j.NotifyConfig, &j.RetryConfig, &j.PingConfig, &j.SlackConfig, &j.MailConfig,
+			&j.GotifyConfig, &j.TeamsConfig, &j.WebhookConfig,
+		)
+		if j.ComposeService == "" {
+			v.container(section, j.Container, false)
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.RunJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+
+		section := jobSection(jobRun, name)
+		v.file = c.jobSource(name)
+		v.job(sh, section, j)
+		if j.Image == "" && j.Container == "" {
+			v.add(section, "image", "image or container is required")
+		}
+
+		v.middlewares(section,
+			&j.NotifyConfig, &j.RetryConfig, &j.PingConfig, &j.SlackConfig, &j.MailConfig,
+			&j.GotifyConfig, &j.TeamsConfig, &j.WebhookConfig,
+		)
+		if j.Image != "" {
+			v.image(section, j.Image)
+		} else {
+			v.container(section, j.Container, false)
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.ServiceJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+
+		section := jobSection(jobServiceRun, name)
+		v.file = c.jobSource(name)
+		v.job(sh, section, j)
+		v.required(section, "image", j.Image)
+		v.middlewares(section,
+			&j.NotifyConfig, &j.RetryConfig, &j.PingConfig, &j.SlackConfig, &j.MailConfig,
+			&j.GotifyConfig, &j.TeamsConfig, &j.WebhookConfig,
+		)
+		v.image(section, j.Image)
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.LocalJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+
+		section := jobSection(jobLocal, name)
+		v.file = c.jobSource(name)
+		v.job(sh, section, j)
+		v.required(section, "command", j.Command)
+		v.middlewares(section,
+			&j.NotifyConfig, &j.RetryConfig, &j.PingConfig, &j.SlackConfig, &j.MailConfig,
+			&j.GotifyConfig, &j.TeamsConfig, &j.WebhookConfig,
+		)
+		v.localCommand(section, j)
+		jobs = append(jobs, j)
+	}
+
+	for name, j := range c.LifecycleJobs {
+		defaults.SetDefaults(j)
+		j.Name = name
+
+		section := jobSection(jobLifecycle, name)
+		v.file = c.jobSource(name)
+		v.required(section, "container", j.Container)
+		v.required(section, "command", j.Command)
+		if j.EventType != core.ContainerStart && j.EventType != core.ContainerStop {
+			v.add(section, "eventtype", "invalid event type %q, must be %q or %q", j.EventType, core.ContainerStart, core.ContainerStop)
+		}
+
+		v.middlewares(section,
+			&j.NotifyConfig, &j.RetryConfig, &j.PingConfig, &j.SlackConfig, &j.MailConfig,
+			&j.GotifyConfig, &j.TeamsConfig, &j.WebhookConfig,
+		)
+		v.container(section, j.Container, true)
+	}
+
+	v.webhooks(c, jobs)
+	v.dependencies(c, jobs)
+}
+
+// webhooks checks the [webhook] sections, and warns about the jobs of
+// webhook-jobs that are not defined
+func (v *validator) webhooks(c *Config, jobs []core.Job) {
+	names := make(map[string]bool, len(jobs))
+	for _, j := range jobs {
+		names[j.GetName()] = true
+	}
+
+	v.file = ""
+	for name, w := range c.Webhooks {
+		section := jobSection(webhookSection, name)
+		v.middlewares(section, w)
+		for _, job := range w.Jobs() {
+			if !names[job] {
+				v.warn(section, "webhook-jobs", "job %q not defined in the configuration", job)
+			}
+		}
+	}
+}
+
+// job checks the schedule and the options shared by all the scheduled jobs
+func (v *validator) job(sh *core.Scheduler, section string, j core.Job) {
+	if _, err := j.GetMaxRuntime(); err != nil {
+		v.add(section, "max-runtime", "%s", err)
+	}
+
+	if err := core.CheckVariables(j.GetCommand()); err != nil {
+		v.add(section, "command", "invalid variables: %s", err)
+	}
+
+	if tz := j.GetTimezone(); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			v.add(section, "timezone", "invalid timezone %q: %s", tz, err)
+			return
+		}
+	}
+
+	if err := sh.ValidateSchedule(j); err != nil {
+		if err == core.ErrEmptySchedule {
+			v.add(section, "schedule", "schedule or depends-on is required")
+			return
+		}
+
+		v.add(section, "schedule", "invalid schedule %q: %s", j.GetSchedule(), err)
+	}
+}
+
+func (v *validator) required(section, key, value string) {
+	if value == "" {
+		v.add(section, key, "%s is required", key)
+	}
+}
+
+// middlewares checks the configuration of the middlewares, the ones with
+// settings that can only fail at run time
+func (v *validator) middlewares(section string, configs ...interface{ Validate() error }) {
+	for _, c := range configs {
+		err := c.Validate()
+		if err == nil {
+			continue
+		}
+
+		var key string
+		var optErr *middlewares.OptionError
+		if errors.As(err, &optErr) {
+			key = optErr.Option
+		}
+
+		v.add(section, key, "%s", err)
+	}
+}
+
+// localCommand checks that the command of a local job can be found, as it
+// would be when the job runs
+func (v *validator) localCommand(section string, j *LocalJobConfig) {
+	if j.Command == "" || strings.Contains(j.Command, "{{") {
+		return
+	}
+
+	name := args.GetArgs(j.Command)[0]
+	if _, err := exec.LookPath(name); err != nil {
+		v.add(section, "command", "%q not found: %s", name, err)
+	}
+}
+
+// container checks that a container exists, only with a Docker client. The
+// containers of the lifecycle jobs may not have been created yet.
+func (v *validator) container(section, name string, lifecycle bool) {
+	if v.client == nil || name == "" {
+		return
+	}
+
+	container, err := v.client.InspectContainer(name)
+	switch {
+	case err != nil && lifecycle:
+		v.warn(section, "container", "container %q not found: %s", name, err)
+	case err != nil:
+		v.add(section, "container", "container %q not found: %s", name, err)
+	case !container.State.Running && !lifecycle:
+		v.warn(section, "container", "container %q is not running", name)
+	}
+}
+
+// image checks that an image exists, the missing ones are pulled when the
+// job runs
+func (v *validator) image(section, name string) {
+	if v.client == nil || name == "" {
+		return
+	}
+
+	ok, err := v.client.ImageExists(name)
+	switch {
+	case err != nil:
+		v.add(section, "image", "cannot inspect image %q: %s", name, err)
+	case !ok:
+		v.warn(section, "image", "image %q not found locally, it will be pulled", name)
+	}
+}
+
+// dependencies checks that the jobs referenced exist, they may be defined by
+// Docker labels, and that they do not contain a cycle
+func (v *validator) dependencies(c *Config, jobs []core.Job) {
+	names := make(map[string]bool, len(jobs))
+	for _, j := range jobs {
+		names[j.GetName()] = true
+	}
+
+	for _, j := range jobs {
+		section := jobSection(core.JobType(j), j.GetName())
+		v.file = c.jobSource(j.GetName())
+		deps := j.GetDependencies()
+		for _, d := range []struct {
+			key   string
+			names []string
+		}{
+			{"depends-on", deps.DependsOn},
+			{"on-success", deps.OnSuccess},
+			{"on-failure", deps.OnFailure},
+		} {
+			for _, name := range d.names {
+				if !names[name] {
+					v.warn(section, d.key, "job %q not defined in the configuration", name)
+				}
+			}
+		}
+	}
+
+	v.file = ""
+	if err := core.CheckDependencies(jobs); err != nil {
+		v.add("", "depends-on", "%s", err)
+	}
+}
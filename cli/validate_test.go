@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteValidate struct{}
+
+var _ = Suite(&SuiteValidate{})
+
+// validateDockerClient knows the running container "db" and the image
+// "busybox"
+type validateDockerClient struct {
+	core.MockDockerClient
+}
+
+func (c *validateDockerClient) InspectContainer(id string) (*core.Container, error) {
+	if id != "db" {
+		return nil, errors.New("no such container")
+	}
+
+	return c.MockDockerClient.InspectContainer(id)
+}
+
+func (c *validateDockerClient) ImageExists(image string) (bool, error) {
+	return image == "busybox", nil
+}
+
+func (s *SuiteValidate) validate(c *C, config string, cmd *ValidateCommand) (string, error) {
+	name := cmd.ConfigFile
+	if name == "" {
+		name = "chadburn.conf"
+	}
+
+	cmd.ConfigFile = filepath.Join(c.MkDir(), name)
+	c.Assert(ioutil.WriteFile(cmd.ConfigFile, []byte(config), 0644), IsNil)
+
+	b := bytes.NewBuffer(nil)
+	cmd.Logger = &TestLogger{}
+	cmd.output = b
+
+	err := cmd.Execute(nil)
+	return b.String(), err
+}
+
+func (s *SuiteValidate) TestValid(c *C) {
+	out, err := s.validate(c, `
+		[global]
+		timezone = Europe/Madrid
+
+		[job-local "backup"]
+		schedule = 0 3 * * *
+		command = true
+
+		[job-local "report"]
+		depends-on = backup
+		command = true
+	`, &ValidateCommand{})
+
+	c.Assert(err, IsNil)
+	c.Assert(out, Equals, "")
+}
+
+func (s *SuiteValidate) TestProblems(c *C) {
+	out, err := s.validate(c, `
+		[global]
+		smtp-host = mail.example.com
+		email-to = ops@example.com
+		slack-webhook = hooks.slack.com/services/XXX
+
+		[job-exec "dump"]
+		schedule = @every 5x
+		comand = pg_dump
+		retry-delay = forever
+		target = every
+
+		[job-local "backup"]
+		schedule = 0 3 * * *
+		command = no-such-binary --all
+		depends-on = missing
+
+		[job-run "cleanup"]
+		schedule = @hourly
+		webhook-url = http://example.com
+		webhook-header = foo
+		gotify-webhook = gotify.example.com
+
+		[job-lifecycle "notify"]
+		container = web
+		command = echo
+	`, &ValidateCommand{})
+
+	c.Assert(err, ErrorMatches, "13 error\\(s\\) found in .*")
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [global] slack-webhook: invalid slack-webhook, must be an http or https URL`,
+		`error: [global] smtp-port: invalid smtp-port 0, must be between 1 and 65535`,
+		`error: [job-exec "dump"] comand: unknown key`,
+		`error: [job-exec "dump"] schedule: invalid schedule "@every 5x": failed to parse duration @every 5x: time: unknown unit "x" in duration "5x"`,
+		`error: [job-exec "dump"] container: container or compose-service is required`,
+		`error: [job-exec "dump"] command: command is required`,
+		`error: [job-exec "dump"] target: invalid target "every", must be "one", "all" or "random"`,
+		`error: [job-exec "dump"] retry-delay: invalid retry-delay: time: invalid duration "forever"`,
+		`error: [job-lifecycle "notify"] eventtype: invalid event type "", must be "start" or "stop"`,
+		`error: [job-local "backup"] command: "no-such-binary" not found: exec: "no-such-binary": executable file not found in $PATH`,
+		`warning: [job-local "backup"] depends-on: job "missing" not defined in the configuration`,
+		`error: [job-run "cleanup"] image: image or container is required`,
+		`error: [job-run "cleanup"] gotify-webhook: invalid gotify-webhook, must be an http or https URL`,
+		`error: [job-run "cleanup"] webhook-header: invalid webhook-header "foo", must be "Name: value"`,
+	})
+}
+
+func (s *SuiteValidate) TestWebhooks(c *C) {
+	out, err := s.validate(c, `
+		[job-local "backup"]
+		schedule = @daily
+		command = true
+
+		[webhook "pagerduty"]
+		webhook-jobs = backup, missing
+
+		[webhook "chat"]
+		webhook-url = https://chat.example.com/hook
+		webhook-header = foo
+	`, &ValidateCommand{})
+
+	c.Assert(err, ErrorMatches, "2 error\\(s\\) found in .*")
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [webhook "chat"] webhook-header: invalid webhook-header "foo", must be "Name: value"`,
+		`error: [webhook "pagerduty"] webhook-url: webhook-url is required`,
+		`warning: [webhook "pagerduty"] webhook-jobs: job "missing" not defined in the configuration`,
+	})
+}
+
+func (s *SuiteValidate) TestYAML(c *C) {
+	out, err := s.validate(c, `
+job-local:
+  backup:
+    schedule: "@every 1x"
+    command: "true"
+    retry-dealy: 10s
+`, &ValidateCommand{ConfigFile: "chadburn.yaml"})
+
+	c.Assert(err, NotNil)
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [job-local "backup"] retry-dealy: unknown key`,
+		`error: [job-local "backup"] schedule: invalid schedule "@every 1x": failed to parse duration @every 1x: time: unknown unit "x" in duration "1x"`,
+	})
+}
+
+func (s *SuiteValidate) TestCommandVariables(c *C) {
+	out, err := s.validate(c, `
+		[job-local "backup"]
+		schedule = @daily
+		command = pg_dump -f dump-{{.Now | date `+"`20060102`"+`}}.sql
+
+		[job-local "report"]
+		schedule = @daily
+		command = report {{.Job.Name | uper}}
+
+		[job-local "cleanup"]
+		schedule = @daily
+		command = rm -f dump-{{.Now | date "20060102"}}.sql
+	`, &ValidateCommand{})
+
+	c.Assert(err, ErrorMatches, "2 error\\(s\\) found in .*")
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [job-local "cleanup"] command: invalid variables: template: command:1:25: executing "command" at <20060102>: expected string; found 20060102`,
+		`error: [job-local "report"] command: invalid variables: template: command:1: function "uper" not defined`,
+	})
+}
+
+func (s *SuiteValidate) TestIncludedFile(c *C) {
+	cmd := &ValidateCommand{}
+	dir := c.MkDir()
+	included := filepath.Join(dir, "report.conf")
+	c.Assert(ioutil.WriteFile(included, []byte(`
+		[job-local "report"]
+		depends-on = backup
+		command = true
+		foo = bar
+	`), 0644), IsNil)
+
+	out, err := s.validate(c, `
+		[global]
+		include = `+included+`
+
+		[job-local "backup"]
+		command = true
+	`, cmd)
+
+	c.Assert(err, NotNil)
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [job-local "backup"] schedule: schedule or depends-on is required`,
+		`error: ` + included + `: [job-local "report"] foo: unknown key`,
+	})
+}
+
+func (s *SuiteValidate) TestSyntaxError(c *C) {
+	out, err := s.validate(c, "[job-local \"foo\"\n", &ValidateCommand{})
+	c.Assert(err, NotNil)
+	c.Assert(out, Matches, "error: .*\n")
+}
+
+func (s *SuiteValidate) TestDocker(c *C) {
+	out, err := s.validate(c, `
+		[job-exec "dump"]
+		schedule = @daily
+		container = db
+		command = pg_dump
+
+		[job-exec "cache"]
+		schedule = @daily
+		container = web
+		command = clear
+
+		[job-exec "reindex"]
+		schedule = @daily
+		compose-service = search
+		command = reindex
+
+		[job-run "busybox"]
+		schedule = @daily
+		image = busybox
+
+		[job-service-run "alpine"]
+		schedule = @daily
+		image = alpine
+	`, &ValidateCommand{Docker: true, client: &validateDockerClient{}})
+
+	c.Assert(err, ErrorMatches, "1 error\\(s\\) found in .*")
+	c.Assert(strings.Split(strings.TrimSpace(out), "\n"), DeepEquals, []string{
+		`error: [job-exec "cache"] container: container "web" not found: no such container`,
+		`warning: [job-service-run "alpine"] image: image "alpine" not found locally, it will be pulled`,
+	})
+}
+
+func (s *SuiteValidate) TestJSON(c *C) {
+	out, err := s.validate(c, `
+		[job-local "backup"]
+		command = true
+	`, &ValidateCommand{JSON: true})
+
+	c.Assert(err, NotNil)
+
+	var result struct {
+		Valid    bool
+		Problems []Problem
+	}
+
+	c.Assert(json.Unmarshal([]byte(out), &result), IsNil)
+	c.Assert(result.Valid, Equals, false)
+	c.Assert(result.Problems, DeepEquals, []Problem{{
+		Section: `job-local "backup"`,
+		Key:     "schedule",
+		Message: "schedule or depends-on is required",
+	}})
+}
@@ -1,19 +1,35 @@
 package core
 
 import (
+	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type BareJob struct {
 	Schedule string `hash:"true"`
 	Name     string `hash:"true"`
 	Command  string `hash:"true"`
+	// MaxRuntime is the maximum duration of an execution, eg.: 30m, once
+	// exceeded the workload is killed and the execution fails
+	MaxRuntime string `gcfg:"max-runtime" mapstructure:"max-runtime" hash:"true"`
+	// MaxRuntimeRestart restarts the container when the processes of a
+	// command exceeding the max-runtime cannot be killed, only the jobs
+	// running an exec in a container use it
+	MaxRuntimeRestart bool `gcfg:"max-runtime-restart" mapstructure:"max-runtime-restart" hash:"true"`
+	// DependsOn, OnSuccess and OnFailure are comma separated lists of job
+	// names, see Dependencies
+	DependsOn string `gcfg:"depends-on" mapstructure:"depends-on" hash:"true"`
+	OnSuccess string `gcfg:"on-success" mapstructure:"on-success" hash:"true"`
+	OnFailure string `gcfg:"on-failure" mapstructure:"on-failure" hash:"true"`
+	// Timezone is the IANA time zone of the schedule, eg.: Europe/Madrid,
+	// overriding the one of the scheduler
+	Timezone string `gcfg:"timezone" mapstructure:"timezone" hash:"true"`
 
 	middlewareContainer
 	running int32
 	lock    sync.Mutex
-	history []*Execution
 	cronID  int
 }
 
@@ -29,14 +45,40 @@ func (j *BareJob) GetCommand() string {
 	return j.Command
 }
 
-// GetProcessedCommand returns the command with variables replaced
-func (j *BareJob) GetProcessedCommand(context VariableContext) string {
+// GetTimezone returns the time zone of the schedule, empty to use the one of
+// the scheduler
+func (j *BareJob) GetTimezone() string {
+	return j.Timezone
+}
+
+// GetMaxRuntime returns the parsed MaxRuntime, zero means no limit
+func (j *BareJob) GetMaxRuntime() (time.Duration, error) {
+	if j.MaxRuntime == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(j.MaxRuntime)
+}
+
+// GetDependencies returns the relations of the job with other jobs
+func (j *BareJob) GetDependencies() Dependencies {
+	return Dependencies{
+		DependsOn: splitList(j.DependsOn),
+		OnSuccess: splitList(j.OnSuccess),
+		OnFailure: splitList(j.OnFailure),
+	}
+}
+
+// GetProcessedCommand returns the command with variables replaced, the
+// errors of the variables fail the execution instead of running the command
+// as is
+func (j *BareJob) GetProcessedCommand(context VariableContext) (string, error) {
 	processed, err := ProcessVariables(j.Command, context)
 	if err != nil {
-		// If there's an error processing variables, return the original command
-		return j.Command
+		return "", fmt.Errorf("error processing the variables of the command: %s", err)
 	}
-	return processed
+
+	return processed, nil
 }
 
 func (j *BareJob) Running() int32 {
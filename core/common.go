@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -22,6 +24,37 @@ var (
 	ErrLocalImageNotFound = errors.New("couldn't find image on the host")
 )
 
+// ExitCodeError is returned when the command of a job exits with a non-zero
+// exit code
+type ExitCodeError struct {
+	Code int
+}
+
+// NewExitCodeError returns an ExitCodeError for the given exit code
+func NewExitCodeError(code int) *ExitCodeError {
+	return &ExitCodeError{Code: code}
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("error non-zero exit code: %d", e.Code)
+}
+
+// ExitCode returns the exit code of the command
+func (e *ExitCodeError) ExitCode() int {
+	return e.Code
+}
+
+// GetExitCode returns the exit code carried by the error, it understands
+// ExitCodeError and the errors returned by os/exec
+func GetExitCode(err error) (int, bool) {
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode(), true
+	}
+
+	return 0, false
+}
+
 // maximum size of a stdout/stderr stream to be kept in memory and optional stored/sent via mail
 const maxStreamSize = 10 * 1024 * 1024
 
@@ -29,7 +62,10 @@ type Job interface {
 	GetName() string
 	GetSchedule() string
 	GetCommand() string
-	GetProcessedCommand(VariableContext) string
+	GetTimezone() string
+	GetMaxRuntime() (time.Duration, error)
+	GetDependencies() Dependencies
+	GetProcessedCommand(VariableContext) (string, error)
 	Middlewares() []Middleware
 	Use(...Middleware)
 	Run(*Context) error
@@ -41,6 +77,16 @@ type Job interface {
 	SetVolumeMounts([]string)
 }
 
+// JobType returns the configuration section name of a job, for the jobs
+// implementing GetType, "unknown" otherwise
+func JobType(j Job) string {
+	if t, ok := j.(interface{ GetType() string }); ok {
+		return t.GetType()
+	}
+
+	return "unknown"
+}
+
 type Context struct {
 	Scheduler   *Scheduler
 	Logger      Logger
@@ -49,6 +95,10 @@ type Context struct {
 	middlewares []Middleware
 	current     int
 	executed    bool
+	retry       RetryPolicy
+
+	notify       NotifyPolicy
+	notification *Notification
 }
 
 // NewContext creates a new Context
@@ -86,7 +136,7 @@ func (c *Context) Run() error {
 	}
 
 	c.executed = true
-	return c.Job.Run(c)
+	return c.runAttempts()
 }
 
 func (c *Context) getNext() (Middleware, bool) {
@@ -108,16 +158,43 @@ func (c *Context) Stop(err error) {
 }
 
 func (c *Context) Log(msg string) {
+	c.LogEvent("", msg, nil)
+}
+
+// LogEvent logs an event of the execution, with the level matching its
+// status. The FieldLoggers receive the job, its type, the execution and the
+// given fields as fields, the others only the message.
+func (c *Context) LogEvent(event, msg string, f Fields) {
+	logger := c.Logger
 	format := "[Job %q (%s)] %s"
 	args := []interface{}{c.Job.GetName(), c.Execution.ID, msg}
 
+	if fl, ok := c.Logger.(FieldLogger); ok {
+		fields := Fields{
+			"job":       c.Job.GetName(),
+			"job_type":  JobType(c.Job),
+			"execution": c.Execution.ID,
+		}
+
+		if event != "" {
+			fields["event"] = event
+		}
+
+		for k, v := range f {
+			fields[k] = v
+		}
+
+		logger = fl.WithFields(fields)
+		format, args = "%s", []interface{}{msg}
+	}
+
 	switch {
 	case c.Execution.Failed():
-		c.Logger.Errorf(format, args...)
+		logger.Errorf(format, args...)
 	case c.Execution.Skipped():
-		c.Logger.Warningf(format, args...)
+		logger.Warningf(format, args...)
 	default:
-		c.Logger.Noticef(format, args...)
+		logger.Noticef(format, args...)
 	}
 }
 
@@ -132,20 +209,67 @@ type Execution struct {
 	start        time.Time
 	err          error
 	end          time.Time
+	// Attempts contains every run of the job in this execution, there is more
+	// than one when the execution is retried
+	Attempts []*Attempt
+	// Upstream is the execution that triggered this one, empty if the
+	// execution wasn't triggered by another job
+	Upstream UpstreamInfo
+	// ExitCode is the exit code of the command, -1 if the command didn't
+	// exit by itself or the job failed before running it
+	ExitCode int
+	// Signal is the name of the signal that killed the command, if any
+	Signal string
+	// ContainerRestarted is true when the container was restarted to stop a
+	// command exceeding the max-runtime, its processes couldn't be killed
+	ContainerRestarted bool
+	// KillError is why the command exceeding the max-runtime couldn't be
+	// killed, it may still be running
+	KillError string
+	// ContainerID is the container where the command was run
+	ContainerID string
+	// ExecID is the Docker exec instance that run the command
+	ExecID string
+	// Host is the host where the command was run
+	Host string
+	// Attempt is the number of the current attempt, starting at 1
+	Attempt int
+	// Command is the command run, with its variables already replaced
+	Command string
+	// ScheduledTime is the time the execution was scheduled for, the time it
+	// was requested for the executions triggered manually or by other jobs
+	ScheduledTime time.Time
+	// Stdout and Stderr, if set, receive a copy of the output of the command
+	// while it runs, eg. to stream it to a terminal
+	Stdout io.Writer `json:"-"`
+	Stderr io.Writer `json:"-"`
 }
 
 func NewExecution() *Execution {
 	stdout, _ := circbuf.NewBuffer(maxStreamSize)
 	stderr, _ := circbuf.NewBuffer(maxStreamSize)
 
+	host, _ := os.Hostname()
+
+	now := time.Now()
 	return &Execution{
-		ID:           randomID(),
-		OutputStream: stdout,
-		ErrorStream:  stderr,
-		Date:         time.Now(),
+		ID:            randomID(),
+		OutputStream:  stdout,
+		ErrorStream:   stderr,
+		Date:          now,
+		ExitCode:      -1,
+		Host:          host,
+		ScheduledTime: now,
 	}
 }
 
+// resetOutput gives the execution new output buffers, the previous ones are
+// kept by the attempt that wrote them
+func (e *Execution) resetOutput() {
+	e.OutputStream, _ = circbuf.NewBuffer(maxStreamSize)
+	e.ErrorStream, _ = circbuf.NewBuffer(maxStreamSize)
+}
+
 func (e *Execution) Start() {
 	e.start = time.Now()
 }
@@ -155,6 +279,20 @@ func (e *Execution) Stop(err error) {
 	e.end = time.Now()
 }
 
+// setExitCode records the exit code of the command from the error returned
+// by the job
+func (e *Execution) setExitCode(err error) int {
+	if err == nil {
+		e.ExitCode = 0
+	} else if code, ok := GetExitCode(err); ok {
+		e.ExitCode = code
+	} else {
+		e.ExitCode = -1
+	}
+
+	return e.ExitCode
+}
+
 func (e *Execution) Error() error {
 	return e.err
 }
@@ -176,10 +314,24 @@ func (e *Execution) Duration() time.Duration {
 		return 0
 	}
 
-	end := time.Now()
+	end := e.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+
 	return end.Sub(e.start)
 }
 
+// StartTime returns the time when the execution was started
+func (e *Execution) StartTime() time.Time {
+	return e.start
+}
+
+// EndTime returns the time when the execution was stopped, zero if still running
+func (e *Execution) EndTime() time.Time {
+	return e.end
+}
+
 // Middleware can wrap any job execution, allowing to execution code before
 // or/and after of each `Job.Run`
 type Middleware interface {
@@ -278,6 +430,10 @@ func getHash(t reflect.Type, v reflect.Value, hash *string) {
 				*hash += strconv.FormatInt(fieldv.Int(), 10)
 			} else if kind == reflect.Bool {
 				*hash += strconv.FormatBool(fieldv.Bool())
+			} else if kind == reflect.Slice && field.Type.Elem().Kind() == reflect.String {
+				for j := 0; j < fieldv.Len(); j++ {
+					*hash += strconv.Quote(fieldv.Index(j).String())
+				}
 			} else {
 				panic("Unsupported field type")
 			}
@@ -191,6 +191,71 @@ func (s *SuiteCommon) TestContextNext(c *C) {
 	c.Assert(j.Called, Equals, 2)
 }
 
+func (s *SuiteCommon) TestContextRetry(c *C) {
+	j := &TestFlakyJob{Failures: 2}
+	m := &TestMiddleware{Nested: true}
+	j.Use(m)
+
+	ctx := NewContext(NewScheduler(&TestLogger{}), j, NewExecution())
+	ctx.SetRetryPolicy(&TestRetryPolicy{Count: 3})
+
+	err := ctx.Run()
+	c.Assert(err, IsNil)
+	c.Assert(m.Called, Equals, 1)
+	c.Assert(j.Called, Equals, 3)
+	c.Assert(ctx.Execution.Attempts, HasLen, 3)
+	c.Assert(ctx.Execution.Attempts[0].Number, Equals, 1)
+	c.Assert(ctx.Execution.Attempts[0].Err, ErrorMatches, "error non-zero exit code: 1")
+	c.Assert(ctx.Execution.Attempts[2].Err, IsNil)
+	c.Assert(ctx.Execution.Attempts[0].ExitCode, Equals, 1)
+	c.Assert(ctx.Execution.Attempt, Equals, 3)
+	c.Assert(ctx.Execution.ExitCode, Equals, 0)
+	c.Assert(ctx.Execution.OutputStream.String(), Equals, "attempt 3")
+	c.Assert(ctx.Execution.Attempts[0].OutputStream.String(), Equals, "attempt 1")
+	c.Assert(ctx.Execution.Attempts[1].OutputStream.String(), Equals, "attempt 2")
+	c.Assert(ctx.Execution.Attempts[2].OutputStream, Equals, ctx.Execution.OutputStream)
+}
+
+func (s *SuiteCommon) TestContextRetryExhausted(c *C) {
+	j := &TestFlakyJob{Failures: 5}
+
+	ctx := NewContext(NewScheduler(&TestLogger{}), j, NewExecution())
+	ctx.SetRetryPolicy(&TestRetryPolicy{Count: 2})
+
+	err := ctx.Run()
+	c.Assert(err, ErrorMatches, "error non-zero exit code: 1")
+	c.Assert(j.Called, Equals, 3)
+	c.Assert(ctx.Execution.Attempts, HasLen, 3)
+	c.Assert(ctx.Execution.ExitCode, Equals, 1)
+}
+
+func (s *SuiteCommon) TestExecutionExitCode(c *C) {
+	e := NewExecution()
+	c.Assert(e.ExitCode, Equals, -1)
+
+	c.Assert(e.setExitCode(nil), Equals, 0)
+	c.Assert(e.setExitCode(NewExitCodeError(3)), Equals, 3)
+	c.Assert(e.setExitCode(errors.New("foo")), Equals, -1)
+}
+
+func (s *SuiteCommon) TestGetExitCode(c *C) {
+	code, ok := GetExitCode(fmt.Errorf("wrapped: %w", NewExitCodeError(42)))
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, 42)
+
+	_, ok = GetExitCode(errors.New("foo"))
+	c.Assert(ok, Equals, false)
+}
+
+func (s *SuiteCommon) TestHashSlices(c *C) {
+	a := &RunJob{Volume: []string{"/foo:/foo", "/bar:/bar"}}
+	b := &RunJob{Volume: []string{"/foo:/foo"}}
+	c.Assert(a.Hash(), Not(Equals), b.Hash())
+
+	b.Volume = append(b.Volume, "/bar:/bar")
+	c.Assert(a.Hash(), Equals, b.Hash())
+}
+
 func (s *SuiteCommon) TestExecutionStart(c *C) {
 	exe := NewExecution()
 	exe.Start()
@@ -316,6 +381,30 @@ func (j *TestJob) Run(ctx *Context) error {
 	return nil
 }
 
+type TestFlakyJob struct {
+	BareJob
+	Failures int
+	Called   int
+}
+
+func (j *TestFlakyJob) Run(ctx *Context) error {
+	j.Called++
+	fmt.Fprintf(ctx.Execution.OutputStream, "attempt %d", j.Called)
+	if j.Called <= j.Failures {
+		return NewExitCodeError(1)
+	}
+
+	return nil
+}
+
+type TestRetryPolicy struct {
+	Count int
+}
+
+func (p *TestRetryPolicy) Retry(attempt int, err error) (time.Duration, bool) {
+	return 0, attempt <= p.Count
+}
+
 type TestLogger struct{}
 
 func (*TestLogger) Criticalf(format string, args ...interface{}) {}
@@ -330,3 +419,27 @@ func (s *SuiteCommon) TestParseRegistry(c *C) {
 	c.Assert(parseRegistry("dir/image"), Equals, "")
 	c.Assert(parseRegistry("image"), Equals, "")
 }
+
+func (s *SuiteCommon) TestWithFields(c *C) {
+	l := &TestRecordLogger{}
+	WithFields(l, Fields{"b": 2, "a": "foo"}).Errorf("100%% %s", "done")
+	c.Assert(l.lines, DeepEquals, []string{"100% done a=foo b=2"})
+
+	l.lines = nil
+	NewCronUtils(l).Error(errors.New("bar"), "failed", "entry", 1)
+	c.Assert(l.lines, DeepEquals, []string{"failed entry=1 error=bar"})
+}
+
+type TestRecordLogger struct {
+	lines []string
+}
+
+func (l *TestRecordLogger) Criticalf(format string, args ...interface{}) { l.record(format, args) }
+func (l *TestRecordLogger) Debugf(format string, args ...interface{})    { l.record(format, args) }
+func (l *TestRecordLogger) Errorf(format string, args ...interface{})    { l.record(format, args) }
+func (l *TestRecordLogger) Noticef(format string, args ...interface{})   { l.record(format, args) }
+func (l *TestRecordLogger) Warningf(format string, args ...interface{})  { l.record(format, args) }
+
+func (l *TestRecordLogger) record(format string, args []interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
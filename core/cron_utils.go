@@ -10,9 +10,12 @@ func NewCronUtils(l Logger) *CronUtils {
 }
 
 func (c *CronUtils) Info(msg string, keysAndValues ...interface{}) {
-	c.Logger.Debugf(msg) // TODO, pass in the keysAndValues
+	WithFields(c.Logger, fieldsFromKeysAndValues(keysAndValues)).Debugf("%s", msg)
 }
 
 func (c *CronUtils) Error(err error, msg string, keysAndValues ...interface{}) {
-	c.Logger.Errorf("msg: %v, error: %v", msg, err) // TODO, pass in the keysAndValues
+	f := fieldsFromKeysAndValues(keysAndValues)
+	f["error"] = err
+
+	WithFields(c.Logger, f).Errorf("%s", msg)
 }
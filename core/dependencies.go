@@ -0,0 +1,213 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TriggeredSchedule is the schedule of the jobs that are only run when they
+// are triggered by other jobs, a job with DependsOn may leave it empty
+const TriggeredSchedule = "@triggered"
+
+// Dependencies are the relations of a job with other jobs:
+//   - DependsOn: the job runs after all these jobs have succeeded
+//   - OnSuccess: these jobs run after the job succeeds
+//   - OnFailure: these jobs run after the job fails
+type Dependencies struct {
+	DependsOn []string
+	OnSuccess []string
+	OnFailure []string
+}
+
+// IsEmpty returns true if the job has no relation with other jobs
+func (d Dependencies) IsEmpty() bool {
+	return len(d.DependsOn) == 0 && len(d.OnSuccess) == 0 && len(d.OnFailure) == 0
+}
+
+// IsTriggered returns true if the job is run only when triggered by others
+func IsTriggered(j Job) bool {
+	schedule := j.GetSchedule()
+	if schedule == TriggeredSchedule {
+		return true
+	}
+
+	return schedule == "" && len(j.GetDependencies().DependsOn) > 0
+}
+
+// CheckDependencies returns an error if the dependencies of the given jobs
+// contain a cycle. References to jobs not present in the list are ignored,
+// they may be defined later by Docker labels.
+func CheckDependencies(jobs []Job) error {
+	// edges from every job to the jobs triggered by it
+	edges := make(map[string][]string, len(jobs))
+	names := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		name := j.GetName()
+		names = append(names, name)
+
+		deps := j.GetDependencies()
+		for _, upstream := range deps.DependsOn {
+			edges[upstream] = append(edges[upstream], name)
+		}
+
+		for _, downstream := range append(deps.OnSuccess, deps.OnFailure...) {
+			edges[name] = append(edges[name], downstream)
+		}
+	}
+
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(names))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					cycle := append(append([]string{}, path[i:]...), name)
+					return fmt.Errorf("job dependencies contain a cycle: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, next := range edges[name] {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// triggerDependents runs the jobs depending on the finished execution of j
+func (s *Scheduler) triggerDependents(j Job, e *Execution) {
+	if e.Skipped() {
+		return
+	}
+
+	name := j.GetName()
+	deps := j.GetDependencies()
+
+	targets := deps.OnSuccess
+	if e.Failed() {
+		targets = deps.OnFailure
+	}
+
+	var jobs []Job
+	for _, target := range targets {
+		if t := s.GetJob(target); t != nil {
+			jobs = append(jobs, t)
+			continue
+		}
+
+		s.Logger.Warningf("Job %q cannot trigger unknown job %q", name, target)
+	}
+
+	s.mutex.Lock()
+	for _, d := range s.Jobs {
+		if !containsString(d.GetDependencies().DependsOn, name) {
+			continue
+		}
+
+		dname := d.GetName()
+		if e.Failed() {
+			delete(s.satisfied[dname], name)
+			continue
+		}
+
+		if s.satisfied[dname] == nil {
+			s.satisfied[dname] = make(map[string]bool)
+		}
+
+		s.satisfied[dname][name] = true
+		if s.isSatisfied(d) {
+			delete(s.satisfied, dname)
+			jobs = append(jobs, d)
+		}
+	}
+	s.mutex.Unlock()
+
+	triggered := make(map[Job]bool, len(jobs))
+	for _, d := range jobs {
+		if triggered[d] {
+			continue
+		}
+
+		triggered[d] = true
+		s.trigger(d, j, e)
+	}
+}
+
+// isSatisfied returns true if all the jobs the given job depends on have
+// succeeded since its last triggered execution
+func (s *Scheduler) isSatisfied(j Job) bool {
+	for _, upstream := range j.GetDependencies().DependsOn {
+		if !s.satisfied[j.GetName()][upstream] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Scheduler) trigger(j Job, upstream Job, ue *Execution) {
+	if s.IsPaused(j) {
+		s.Logger.Noticef("Job %q is paused, not triggered by %q (%s)", j.GetName(), upstream.GetName(), ue.ID)
+		return
+	}
+
+	e := NewExecution()
+	e.Upstream = UpstreamInfo{
+		Job:         upstream.GetName(),
+		ExecutionID: ue.ID,
+		Failed:      ue.Failed(),
+	}
+
+	s.Logger.Noticef("Job %q triggered by %q (%s)", j.GetName(), upstream.GetName(), ue.ID)
+	w := &jobWrapper{s, j}
+	go w.run(e)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteDependencies struct{}
+
+var _ = Suite(&SuiteDependencies{})
+
+func (s *SuiteDependencies) TestGetDependencies(c *C) {
+	j := &BareJob{DependsOn: "dump, compress", OnSuccess: "upload", OnFailure: " ,alert "}
+
+	deps := j.GetDependencies()
+	c.Assert(deps.DependsOn, DeepEquals, []string{"dump", "compress"})
+	c.Assert(deps.OnSuccess, DeepEquals, []string{"upload"})
+	c.Assert(deps.OnFailure, DeepEquals, []string{"alert"})
+	c.Assert(deps.IsEmpty(), Equals, false)
+	c.Assert((&BareJob{}).GetDependencies().IsEmpty(), Equals, true)
+}
+
+func (s *SuiteDependencies) TestIsTriggered(c *C) {
+	c.Assert(IsTriggered(&TestJob{BareJob: BareJob{Schedule: "@hourly", DependsOn: "foo"}}), Equals, false)
+	c.Assert(IsTriggered(&TestJob{BareJob: BareJob{DependsOn: "foo"}}), Equals, true)
+	c.Assert(IsTriggered(&TestJob{BareJob: BareJob{Schedule: TriggeredSchedule}}), Equals, true)
+	c.Assert(IsTriggered(&TestJob{BareJob: BareJob{}}), Equals, false)
+}
+
+func (s *SuiteDependencies) TestCheckDependencies(c *C) {
+	jobs := []Job{
+		&TestJob{BareJob: BareJob{Name: "dump", OnSuccess: "compress"}},
+		&TestJob{BareJob: BareJob{Name: "compress"}},
+		&TestJob{BareJob: BareJob{Name: "upload", DependsOn: "compress, external"}},
+	}
+
+	c.Assert(CheckDependencies(jobs), IsNil)
+}
+
+func (s *SuiteDependencies) TestCheckDependenciesCycle(c *C) {
+	jobs := []Job{
+		&TestJob{BareJob: BareJob{Name: "a", OnSuccess: "b", DependsOn: "c"}},
+		&TestJob{BareJob: BareJob{Name: "b"}},
+		&TestJob{BareJob: BareJob{Name: "c", DependsOn: "b"}},
+	}
+
+	err := CheckDependencies(jobs)
+	c.Assert(err, ErrorMatches, "job dependencies contain a cycle: a -> b -> c -> a")
+}
+
+func (s *SuiteDependencies) TestCheckDependenciesSelf(c *C) {
+	err := CheckDependencies([]Job{&TestJob{BareJob: BareJob{Name: "a", OnFailure: "a"}}})
+	c.Assert(err, ErrorMatches, "job dependencies contain a cycle: a -> a")
+}
+
+func (s *SuiteDependencies) TestAddTriggeredJob(c *C) {
+	job := &TestJob{}
+	job.DependsOn = "foo"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+	c.Assert(sc.cron.Entries(), HasLen, 0)
+	c.Assert(sc.GetJob(job.GetName()), Equals, job)
+}
+
+func (s *SuiteDependencies) TestOnSuccessOnFailure(c *C) {
+	sc := NewScheduler(&TestLogger{})
+
+	ok := &TestRecorderJob{}
+	ok.Name, ok.Schedule, ok.OnSuccess, ok.OnFailure = "ok", "@hourly", "success", "failure"
+	success := &TestRecorderJob{done: make(chan *Execution, 1)}
+	success.Name, success.Schedule = "success", TriggeredSchedule
+	failure := &TestRecorderJob{done: make(chan *Execution, 1)}
+	failure.Name, failure.Schedule = "failure", TriggeredSchedule
+
+	for _, j := range []Job{ok, success, failure} {
+		c.Assert(sc.AddJob(j), IsNil)
+	}
+
+	e := sc.RunJob(ok)
+
+	select {
+	case te := <-success.done:
+		c.Assert(te.Upstream, Equals, UpstreamInfo{Job: "ok", ExecutionID: e.ID})
+	case <-time.After(2 * time.Second):
+		c.Fatal("on-success job not triggered")
+	}
+
+	c.Assert(failure.Runs(), Equals, 0)
+
+	ok.err = errors.New("foo")
+	e = sc.RunJob(ok)
+
+	select {
+	case te := <-failure.done:
+		c.Assert(te.Upstream, Equals, UpstreamInfo{Job: "ok", ExecutionID: e.ID, Failed: true})
+	case <-time.After(2 * time.Second):
+		c.Fatal("on-failure job not triggered")
+	}
+}
+
+func (s *SuiteDependencies) TestDependsOnAll(c *C) {
+	sc := NewScheduler(&TestLogger{})
+
+	a := &TestRecorderJob{}
+	a.Name, a.Schedule = "a", "@hourly"
+	b := &TestRecorderJob{}
+	b.Name, b.Schedule = "b", "@hourly"
+	d := &TestRecorderJob{done: make(chan *Execution, 2)}
+	d.Name, d.DependsOn = "d", "a, b"
+
+	for _, j := range []Job{a, b, d} {
+		c.Assert(sc.AddJob(j), IsNil)
+	}
+
+	runAndWait(sc, a)
+	runAndWait(sc, a)
+	c.Assert(d.Runs(), Equals, 0)
+
+	e := runAndWait(sc, b)
+
+	select {
+	case te := <-d.done:
+		c.Assert(te.Upstream.Job, Equals, "b")
+		c.Assert(te.Upstream.ExecutionID, Equals, e.ID)
+	case <-time.After(2 * time.Second):
+		c.Fatal("depends-on job not triggered")
+	}
+
+	// the dependencies must be satisfied again
+	runAndWait(sc, b)
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(d.Runs(), Equals, 1)
+}
+
+func (s *SuiteDependencies) TestDependsOnReplaced(c *C) {
+	sc := NewScheduler(&TestLogger{})
+
+	a := &TestRecorderJob{}
+	a.Name, a.Schedule = "a", "@hourly"
+	b := &TestRecorderJob{}
+	b.Name, b.Schedule = "b", "@hourly"
+	d := &TestRecorderJob{}
+	d.Name, d.DependsOn = "d", "a, b"
+
+	for _, j := range []Job{a, b, d} {
+		c.Assert(sc.AddJob(j), IsNil)
+	}
+
+	runAndWait(sc, a)
+
+	// a new version of the job keeps the progress of its dependencies
+	replaced := &TestRecorderJob{done: make(chan *Execution, 1)}
+	replaced.Name, replaced.DependsOn = "d", "a, b"
+	c.Assert(sc.ReplaceJob(d, replaced), IsNil)
+
+	runAndWait(sc, b)
+
+	select {
+	case <-replaced.done:
+	case <-time.After(2 * time.Second):
+		c.Fatal("depends-on job not triggered")
+	}
+
+	c.Assert(d.Runs(), Equals, 0)
+
+	// a removed job loses it
+	runAndWait(sc, a)
+	c.Assert(sc.RemoveJob(replaced), IsNil)
+	c.Assert(sc.satisfied, HasLen, 0)
+}
+
+func (s *SuiteDependencies) TestPausedNotTriggered(c *C) {
+	sc := NewScheduler(&TestLogger{})
+
+	a := &TestRecorderJob{}
+	a.Name, a.Schedule, a.OnSuccess = "a", "@hourly", "b"
+	b := &TestRecorderJob{done: make(chan *Execution, 1)}
+	b.Name, b.Schedule = "b", TriggeredSchedule
+
+	c.Assert(sc.AddJob(a), IsNil)
+	c.Assert(sc.AddJob(b), IsNil)
+	c.Assert(sc.PauseJob(b), IsNil)
+
+	runAndWait(sc, a)
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(b.Runs(), Equals, 0)
+
+	c.Assert(sc.ResumeJob(b), IsNil)
+	c.Assert(sc.cron.Entries(), HasLen, 1)
+}
+
+func (s *SuiteDependencies) TestExecuteJobNotTriggering(c *C) {
+	sc := NewScheduler(&TestLogger{})
+
+	a := &TestRecorderJob{}
+	a.Name, a.Schedule, a.OnSuccess = "a", "@hourly", "b"
+	b := &TestRecorderJob{done: make(chan *Execution, 1)}
+	b.Name, b.Schedule = "b", TriggeredSchedule
+
+	c.Assert(sc.AddJob(a), IsNil)
+	c.Assert(sc.AddJob(b), IsNil)
+
+	sc.ExecuteJob(a, NewExecution())
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(a.Runs(), Equals, 1)
+	c.Assert(b.Runs(), Equals, 0)
+}
+
+// runAndWait runs the job through the scheduler, returning once finished
+func runAndWait(sc *Scheduler, j Job) *Execution {
+	e := NewExecution()
+	(&jobWrapper{sc, j}).run(e)
+	return e
+}
+
+type TestRecorderJob struct {
+	BareJob
+	err  error
+	done chan *Execution
+	runs int
+	mu   sync.Mutex
+}
+
+func (j *TestRecorderJob) Run(ctx *Context) error {
+	j.mu.Lock()
+	j.runs++
+	j.mu.Unlock()
+
+	if j.done != nil {
+		j.done <- ctx.Execution
+	}
+
+	return j.err
+}
+
+func (j *TestRecorderJob) Runs() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.runs
+}
@@ -5,12 +5,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 )
 
@@ -82,8 +84,12 @@ func (c *OfficialDockerClient) ListContainers(filterMap map[string][]string) ([]
 		result[i] = Container{
 			ID:     container.ID,
 			Name:   name,
+			Image:  container.Image,
 			Labels: container.Labels,
 		}
+		if container.NetworkSettings != nil {
+			result[i].IP = containerIP(container.NetworkSettings.Networks)
+		}
 		result[i].Config.Labels = container.Labels
 	}
 
@@ -100,14 +106,36 @@ func (c *OfficialDockerClient) InspectContainer(id string) (*Container, error) {
 	result := &Container{
 		ID:     containerInfo.ID,
 		Name:   strings.TrimPrefix(containerInfo.Name, "/"),
+		Image:  containerInfo.Config.Image,
 		Labels: containerInfo.Config.Labels,
 	}
+	if containerInfo.NetworkSettings != nil {
+		result.IP = containerIP(containerInfo.NetworkSettings.Networks)
+	}
 	result.State.Running = containerInfo.State.Running
 	result.Config.Labels = containerInfo.Config.Labels
 
 	return result, nil
 }
 
+// containerIP returns the address of a container in the first of its
+// networks with one, by name
+func containerIP(networks map[string]*network.EndpointSettings) string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if n := networks[name]; n != nil && n.IPAddress != "" {
+			return n.IPAddress
+		}
+	}
+
+	return ""
+}
+
 // CreateContainer creates a new container
 func (c *OfficialDockerClient) CreateContainer(config *ContainerConfig) (*Container, error) {
 	// Convert our config to Docker's config
@@ -153,6 +181,11 @@ func (c *OfficialDockerClient) StopContainer(id string) error {
 	return c.client.ContainerStop(c.ctx, id, container.StopOptions{})
 }
 
+// RestartContainer stops and starts again a container
+func (c *OfficialDockerClient) RestartContainer(id string) error {
+	return c.client.ContainerRestart(c.ctx, id, container.StopOptions{})
+}
+
 // RemoveContainer removes a container
 func (c *OfficialDockerClient) RemoveContainer(id string) error {
 	return c.client.ContainerRemove(c.ctx, id, container.RemoveOptions{
@@ -171,6 +204,17 @@ func (c *OfficialDockerClient) WaitContainer(id string) (int, error) {
 	}
 }
 
+// ContainerLogs returns the stdout and stderr of a container, multiplexed
+// unless the container has a TTY. With follow the stream is kept open until
+// the container stops.
+func (c *OfficialDockerClient) ContainerLogs(id string, follow bool) (io.ReadCloser, error) {
+	return c.client.ContainerLogs(c.ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+	})
+}
+
 // ReadCloserWrapper wraps a bufio.Reader to implement io.ReadCloser
 type ReadCloserWrapper struct {
 	*bufio.Reader
@@ -194,6 +238,7 @@ func (c *OfficialDockerClient) CreateExec(containerID string, cmd []string, conf
 		AttachStdout: config.AttachStdout,
 		AttachStderr: config.AttachStderr,
 		Cmd:          cmd,
+		Env:          config.Env,
 		WorkingDir:   config.WorkingDir,
 	}
 
@@ -249,6 +294,16 @@ func (c *OfficialDockerClient) PullImage(imageName string) error {
 	return err
 }
 
+// ImageExists returns whether an image is present locally
+func (c *OfficialDockerClient) ImageExists(imageName string) (bool, error) {
+	_, _, err := c.client.ImageInspectWithRaw(c.ctx, imageName)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
 // WatchEvents watches Docker events and sends them to the provided channel
 func (c *OfficialDockerClient) WatchEvents(ctx context.Context, eventCh chan<- *DockerEvent, errCh chan<- error) {
 	// Create a context that can be cancelled
@@ -14,8 +14,10 @@ type DockerClient interface {
 	CreateContainer(config *ContainerConfig) (*Container, error)
 	StartContainer(id string) error
 	StopContainer(id string) error
+	RestartContainer(id string) error
 	RemoveContainer(id string) error
 	WaitContainer(id string) (int, error)
+	ContainerLogs(id string, follow bool) (io.ReadCloser, error)
 
 	// Exec operations
 	CreateExec(containerID string, cmd []string, config *ExecConfig) (string, error)
@@ -24,6 +26,7 @@ type DockerClient interface {
 
 	// Image operations
 	PullImage(image string) error
+	ImageExists(image string) (bool, error)
 
 	// Service operations
 	CreateService(config *ServiceConfig) (string, error)
@@ -48,8 +51,12 @@ type DockerClient interface {
 type Container struct {
 	ID     string
 	Name   string
+	Image  string
 	Labels map[string]string
-	State  struct {
+	// IP is the address of the container in the first of its networks, by
+	// name
+	IP    string
+	State struct {
 		Running bool
 	}
 	Config struct {
@@ -89,6 +96,7 @@ type ExecConfig struct {
 	AttachStdout bool
 	AttachStderr bool
 	Cmd          []string
+	Env          []string
 	WorkingDir   string
 }
 
@@ -168,10 +176,12 @@ type Task struct {
 
 // TaskStatus represents the status of a task
 type TaskStatus struct {
-	Timestamp time.Time
-	State     string
-	Message   string
-	Err       string
+	Timestamp   time.Time
+	State       string
+	Message     string
+	Err         string
+	ContainerID string
+	ExitCode    int
 }
 
 // DockerEvent represents a Docker event
@@ -2,20 +2,38 @@ package core
 
 import (
 	"fmt"
-	"io"
+	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gobs/args"
 )
 
+// The replicas of a Compose service an ExecJob runs in, see ExecJob.Target
+const (
+	TargetOne    = "one"
+	TargetAll    = "all"
+	TargetRandom = "random"
+)
+
 // ExecJob represents a job that executes a command in a running Docker container
 type ExecJob struct {
 	BareJob   `mapstructure:",squash"`
 	Client    DockerClient `json:"-"`
 	Container string       `hash:"true"`
-	User      string       `default:"root" hash:"true"`
-	TTY       bool         `default:"false" hash:"true"`
-	Workdir   string       `default:"" hash:"true"`
+	// ComposeService is the Docker Compose service whose containers run the
+	// command instead of Container, following them across recreations and
+	// scale-outs. ComposeProject restricts it to a project.
+	ComposeService string `gcfg:"compose-service" mapstructure:"compose-service" hash:"true"`
+	ComposeProject string `gcfg:"compose-project" mapstructure:"compose-project" hash:"true"`
+	// Target is the replicas of the service the command runs in: one, the
+	// first by name, all, one after the other, or random
+	Target  string `gcfg:"target" mapstructure:"target" default:"one" hash:"true"`
+	User    string `default:"root" hash:"true"`
+	TTY     bool   `default:"false" hash:"true"`
+	Workdir string `default:"" hash:"true"`
 	// ContainerName is used for variable processing and is not included in the hash
 	ContainerName string `hash:"-"`
 }
@@ -25,17 +43,93 @@ func NewExecJob(c DockerClient) *ExecJob {
 	return &ExecJob{Client: c}
 }
 
+// Run executes the command in the container, or in the replicas of the
+// Compose service selected by Target. With all of them the execution fails
+// if the command fails in any, after running in the others.
 func (j *ExecJob) Run(ctx *Context) error {
-	// Create variable context
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.Container,
-			ID:   j.Container, // We use the container name as ID for now
-		},
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
+	containers, err := j.containers()
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	var first error
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+		ctx.Execution.ContainerID = strings.Join(ids, ",")
+
+		if err := j.exec(ctx, c, max); err != nil && first == nil {
+			first = err
+		}
 	}
 
+	return first
+}
+
+// containers returns the containers the command runs in
+func (j *ExecJob) containers() ([]*Container, error) {
+	if j.ComposeService == "" {
+		c, err := j.Client.InspectContainer(j.Container)
+		if err != nil {
+			return nil, fmt.Errorf("error inspecting container: %s", err)
+		}
+
+		if !c.State.Running {
+			return nil, fmt.Errorf("unable to exec because container %q is not running", j.Container)
+		}
+
+		return []*Container{c}, nil
+	}
+
+	filter := []string{ComposeServiceLabel + "=" + j.ComposeService}
+	if j.ComposeProject != "" {
+		filter = append(filter, ComposeProjectLabel+"="+j.ComposeProject)
+	}
+
+	list, err := j.Client.ListContainers(map[string][]string{"label": filter})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the containers of service %q: %s", j.ComposeService, err)
+	}
+
+	var containers []*Container
+	for i := range list {
+		containers = append(containers, &list[i])
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("unable to exec because service %q has no running container", j.ComposeService)
+	}
+
+	sort.Slice(containers, func(a, b int) bool {
+		return containers[a].Name < containers[b].Name
+	})
+
+	switch j.Target {
+	case TargetAll:
+		return containers, nil
+	case TargetRandom:
+		return []*Container{containers[rand.Intn(len(containers))]}, nil
+	case TargetOne, "":
+		return containers[:1], nil
+	default:
+		return nil, fmt.Errorf("invalid target %q, expected one, all or random", j.Target)
+	}
+}
+
+// exec runs the command in a container
+func (j *ExecJob) exec(ctx *Context, c *Container, max time.Duration) error {
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(NewVariableContext(ctx, NewContainerInfo(c)))
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
 
 	// Parse command
 	cmds := args.GetArgs(processedCommand)
@@ -47,15 +141,18 @@ func (j *ExecJob) Run(ctx *Context) error {
 		AttachStderr: true,
 		Tty:          j.TTY,
 		User:         j.User,
+		Env:          []string{ExecutionIDEnv + "=" + ctx.Execution.ID},
 		WorkingDir:   j.Workdir,
 	}
 
 	// Create exec instance
-	execID, err := j.Client.CreateExec(j.Container, cmds, config)
+	execID, err := j.Client.CreateExec(c.ID, cmds, config)
 	if err != nil {
 		return fmt.Errorf("error creating exec: %s", err)
 	}
 
+	ctx.Execution.ExecID = execID
+
 	// Start exec
 	reader, err := j.Client.StartExec(execID, true, true)
 	if err != nil {
@@ -64,11 +161,8 @@ func (j *ExecJob) Run(ctx *Context) error {
 	defer reader.Close()
 
 	// Copy output to the execution streams
-	if ctx.Execution.OutputStream != nil {
-		_, err = io.Copy(ctx.Execution.OutputStream, reader)
-		if err != nil {
-			return fmt.Errorf("error copying output: %s", err)
-		}
+	if err := copyExecOutput(ctx, j.Client, c.ID, reader, j.TTY, max, j.MaxRuntimeRestart); err != nil {
+		return err
 	}
 
 	// Inspect exec
@@ -83,7 +177,7 @@ func (j *ExecJob) Run(ctx *Context) error {
 	case -1:
 		return ErrUnexpected
 	default:
-		return fmt.Errorf("error non-zero exit code: %d", inspect.ExitCode)
+		return NewExitCodeError(inspect.ExitCode)
 	}
 }
 
@@ -1,6 +1,10 @@
 package core
 
 import (
+	"fmt"
+	"io"
+	"strings"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -28,3 +32,204 @@ func (s *SuiteExecJob) TestRun(c *C) {
 	err := job.Run(&Context{Execution: e})
 	c.Assert(err, IsNil)
 }
+
+func (s *SuiteExecJob) TestRunOutput(c *C) {
+	job := NewExecJob(s.mockClient)
+	job.Container = ContainerFixture
+	job.Command = `echo foo`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e})
+	c.Assert(err, IsNil)
+	c.Assert(e.OutputStream.String(), Equals, "test output")
+	c.Assert(e.ErrorStream.String(), Equals, "test error")
+	c.Assert(e.Command, Equals, "echo foo")
+	c.Assert(e.ContainerID, Equals, ContainerFixture)
+}
+
+func (s *SuiteExecJob) TestRunOutputTTY(c *C) {
+	job := NewExecJob(s.mockClient)
+	job.Container = ContainerFixture
+	job.Command = `echo foo`
+	job.TTY = true
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e})
+	c.Assert(err, IsNil)
+	c.Assert(e.OutputStream.String(), Equals, "test output")
+	c.Assert(e.ErrorStream.String(), Equals, "")
+}
+
+func (s *SuiteExecJob) TestRunComposeService(c *C) {
+	client := newComposeClient("shop-db-2", "shop-db-1", "shop-db-3")
+
+	job := NewExecJob(client)
+	job.ComposeService = "db"
+	job.ComposeProject = "shop"
+	job.Command = `echo {{.Compose.Project}} {{.Compose.Service}} {{.Container.Name}}`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e})
+	c.Assert(err, IsNil)
+	c.Assert(client.filters, DeepEquals, map[string][]string{
+		"label": {ComposeServiceLabel + "=db", ComposeProjectLabel + "=shop"},
+	})
+	c.Assert(client.execs, DeepEquals, []string{"id-shop-db-1"})
+	c.Assert(e.Command, Equals, "echo shop db shop-db-1")
+	c.Assert(e.ContainerID, Equals, "id-shop-db-1")
+}
+
+func (s *SuiteExecJob) TestRunComposeTargets(c *C) {
+	client := newComposeClient("shop-db-2", "shop-db-1")
+
+	job := NewExecJob(client)
+	job.ComposeService = "db"
+	job.Command = `true`
+	job.Target = TargetAll
+
+	e := NewExecution()
+	c.Assert(job.Run(&Context{Execution: e}), IsNil)
+	c.Assert(client.execs, DeepEquals, []string{"id-shop-db-1", "id-shop-db-2"})
+	c.Assert(e.ContainerID, Equals, "id-shop-db-1,id-shop-db-2")
+
+	client.execs = nil
+	job.Target = TargetRandom
+	c.Assert(job.Run(&Context{Execution: NewExecution()}), IsNil)
+	c.Assert(client.execs, HasLen, 1)
+
+	job.Target = "every"
+	c.Assert(job.Run(&Context{Execution: NewExecution()}), ErrorMatches, `invalid target "every".*`)
+
+	client.containers = nil
+	job.Target = TargetOne
+	err := job.Run(&Context{Execution: NewExecution()})
+	c.Assert(err, ErrorMatches, `unable to exec because service "db" has no running container`)
+}
+
+func (s *SuiteExecJob) TestRunMaxRuntime(c *C) {
+	client := &hangingExecClient{}
+
+	job := &OfficialExecJob{Client: client}
+	job.Container = ContainerFixture
+	job.Command = `sleep 3600`
+	job.MaxRuntime = "100ms"
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, Equals, ErrMaxTimeRunning)
+	c.Assert(client.cmds, HasLen, 2)
+	c.Assert(client.cmds[1][0], Equals, "sh")
+	c.Assert(strings.Contains(client.cmds[1][2], ExecutionIDEnv+"="+e.ID), Equals, true)
+	c.Assert(client.envs[0], DeepEquals, []string{ExecutionIDEnv + "=" + e.ID})
+	c.Assert(e.Signal, Equals, "SIGKILL")
+	c.Assert(e.ContainerRestarted, Equals, false)
+}
+
+func (s *SuiteExecJob) TestRunMaxRuntimeKillFails(c *C) {
+	// eg.: a distroless container without sh, tr or grep
+	client := &hangingExecClient{killExitCode: 127}
+
+	job := &OfficialExecJob{Client: client}
+	job.Container = ContainerFixture
+	job.Command = `sleep 3600`
+	job.MaxRuntime = "100ms"
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, Equals, ErrMaxTimeRunning)
+	c.Assert(client.restarted, HasLen, 0)
+	c.Assert(e.Signal, Equals, "")
+	c.Assert(e.ContainerRestarted, Equals, false)
+	c.Assert(e.KillError, Equals, "kill exec exited with code 127")
+}
+
+func (s *SuiteExecJob) TestRunMaxRuntimeKillFailsRestart(c *C) {
+	client := &hangingExecClient{killExitCode: 127}
+
+	job := &OfficialExecJob{Client: client}
+	job.Container = ContainerFixture
+	job.Command = `sleep 3600`
+	job.MaxRuntime = "100ms"
+	job.MaxRuntimeRestart = true
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, Equals, ErrMaxTimeRunning)
+	c.Assert(client.restarted, DeepEquals, []string{ContainerFixture})
+	c.Assert(e.Signal, Equals, "")
+	c.Assert(e.ContainerRestarted, Equals, true)
+	c.Assert(e.KillError, Equals, "")
+}
+
+// hangingExecClient returns execs whose output never ends
+type hangingExecClient struct {
+	MockDockerClient
+	cmds         [][]string
+	envs         [][]string
+	killExitCode int
+	restarted    []string
+}
+
+func (c *hangingExecClient) InspectExec(execID string) (*ExecInspect, error) {
+	return &ExecInspect{ID: execID, ExitCode: c.killExitCode}, nil
+}
+
+func (c *hangingExecClient) RestartContainer(id string) error {
+	c.restarted = append(c.restarted, id)
+	return nil
+}
+
+func (c *hangingExecClient) CreateExec(containerID string, cmd []string, config *ExecConfig) (string, error) {
+	c.cmds = append(c.cmds, cmd)
+	c.envs = append(c.envs, config.Env)
+	return fmt.Sprintf("exec-%d", len(c.cmds)), nil
+}
+
+func (c *hangingExecClient) StartExec(execID string, attachStdout, attachStderr bool) (io.ReadCloser, error) {
+	if execID != "exec-1" {
+		return c.MockDockerClient.StartExec(execID, attachStdout, attachStderr)
+	}
+
+	r, _ := io.Pipe()
+	return r, nil
+}
+
+// composeClient lists the replicas of a Compose service
+type composeClient struct {
+	MockDockerClient
+	containers []Container
+	filters    map[string][]string
+	execs      []string
+}
+
+func newComposeClient(names ...string) *composeClient {
+	client := &composeClient{}
+	for _, name := range names {
+		client.containers = append(client.containers, Container{
+			ID:   "id-" + name,
+			Name: name,
+			Labels: map[string]string{
+				ComposeProjectLabel: "shop",
+				ComposeServiceLabel: "db",
+			},
+		})
+	}
+
+	return client
+}
+
+func (c *composeClient) ListContainers(filters map[string][]string) ([]Container, error) {
+	c.filters = filters
+	return append([]Container(nil), c.containers...), nil
+}
+
+func (c *composeClient) CreateExec(containerID string, cmd []string, config *ExecConfig) (string, error) {
+	c.execs = append(c.execs, containerID)
+	return c.MockDockerClient.CreateExec(containerID, cmd, config)
+}
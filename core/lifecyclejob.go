@@ -40,28 +40,29 @@ func (j *LifecycleJob) Run(ctx *Context) error {
 	}
 
 	// Create variable context with container info
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.Container,
-			ID:   j.Container, // We use the container name as ID for now
-		},
-	}
+	varContext := NewVariableContext(ctx, ContainerInfo{
+		Name: j.Container,
+		ID:   j.Container, // We use the container name as ID for now
+	})
 
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(varContext)
+	if err != nil {
+		return err
+	}
 
 	// Execute the command locally
 	localJob := &LocalJob{}
 	// Set fields individually instead of copying the BareJob struct
 	localJob.Name = j.Name
 	localJob.Schedule = j.Schedule
+	localJob.MaxRuntime = j.MaxRuntime
 	localJob.Command = processedCommand // Use the processed command
 	localJob.ContainerID = j.Container
 	localJob.ContainerName = j.Container
 
 	// Run the local job
-	err := localJob.Run(ctx)
-	if err != nil {
+	if err := localJob.Run(ctx); err != nil {
 		return err
 	}
 
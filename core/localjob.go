@@ -9,8 +9,8 @@ import (
 
 type LocalJob struct {
 	BareJob     `mapstructure:",squash"`
-	Dir         string
-	Environment []string
+	Dir         string   `hash:"true"`
+	Environment []string `hash:"true"`
 	// Variables for command processing
 	ContainerName string `hash:"-"`
 	ContainerID   string `hash:"-"`
@@ -33,20 +33,45 @@ func (j *LocalJob) Run(ctx *Context) error {
 		return err
 	}
 
-	return cmd.Run()
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	ctx.Execution.ContainerID = j.ContainerID
+
+	err = runWithMaxRuntime(max, cmd.Wait, func() {
+		killProcessGroup(cmd)
+	})
+
+	if err == ErrMaxTimeRunning {
+		ctx.Execution.Signal = "SIGKILL"
+	} else {
+		ctx.Execution.Signal = exitSignal(cmd)
+	}
+
+	return err
 }
 
 func (j *LocalJob) buildCommand(ctx *Context) (*exec.Cmd, error) {
 	// Create variable context
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.ContainerName,
-			ID:   j.ContainerID,
-		},
-	}
+	varContext := NewVariableContext(ctx, ContainerInfo{
+		Name: j.ContainerName,
+		ID:   j.ContainerID,
+	})
 
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(varContext)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Execution.Command = processedCommand
 
 	args := args.GetArgs(processedCommand)
 	bin, err := exec.LookPath(args[0])
@@ -54,11 +79,12 @@ func (j *LocalJob) buildCommand(ctx *Context) (*exec.Cmd, error) {
 		return nil, err
 	}
 
+	stdout, stderr := ctx.Execution.outputWriters()
 	return &exec.Cmd{
 		Path:   bin,
 		Args:   args,
-		Stdout: ctx.Execution.OutputStream,
-		Stderr: ctx.Execution.ErrorStream,
+		Stdout: stdout,
+		Stderr: stderr,
 		Env:    j.Environment,
 		Dir:    j.Dir,
 	}, nil
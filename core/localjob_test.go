@@ -1,6 +1,9 @@
 package core
 
 import (
+	"bytes"
+	"time"
+
 	"github.com/armon/circbuf"
 
 	. "gopkg.in/check.v1"
@@ -21,4 +24,55 @@ func (s *SuiteLocalJob) TestRun(c *C) {
 	err := job.Run(&Context{Execution: e})
 	c.Assert(err, IsNil)
 	c.Assert(b.String(), Equals, "foo bar\n")
+	c.Assert(e.Command, Equals, `echo "foo bar"`)
+}
+
+func (s *SuiteLocalJob) TestRunVariables(c *C) {
+	job := &LocalJob{}
+	job.Name = "backup"
+	job.Command = `echo {{.Job.Name}}-{{.Execution.ID}}-{{.Execution.ScheduledTime | date "2006"}}`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Job: job, Execution: e})
+	c.Assert(err, IsNil)
+	c.Assert(e.Command, Equals, "echo backup-"+e.ID+"-"+e.ScheduledTime.Format("2006"))
+
+	// the invalid variables fail the execution instead of running the command
+	job.Command = `echo {{.Job.Missing}}`
+	e = NewExecution()
+
+	err = job.Run(&Context{Job: job, Execution: e})
+	c.Assert(err, ErrorMatches, "error processing the variables of the command: .*Missing.*")
+	c.Assert(e.Command, Equals, "")
+}
+
+func (s *SuiteLocalJob) TestRunStreamOutput(c *C) {
+	job := &LocalJob{}
+	job.Command = `sh -c "echo foo; echo bar >&2"`
+
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	e := NewExecution()
+	e.Stdout, e.Stderr = stdout, stderr
+
+	c.Assert(job.Run(&Context{Execution: e}), IsNil)
+	c.Assert(stdout.String(), Equals, "foo\n")
+	c.Assert(stderr.String(), Equals, "bar\n")
+	c.Assert(e.OutputStream.String(), Equals, "foo\n")
+	c.Assert(e.ErrorStream.String(), Equals, "bar\n")
+}
+
+func (s *SuiteLocalJob) TestRunMaxRuntime(c *C) {
+	job := &LocalJob{}
+	job.Command = `sh -c "sleep 10; echo done"`
+	job.MaxRuntime = "200ms"
+
+	e := NewExecution()
+
+	start := time.Now()
+	err := job.Run(&Context{Execution: e})
+	c.Assert(err, Equals, ErrMaxTimeRunning)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+	c.Assert(e.OutputStream.String(), Equals, "")
+	c.Assert(e.Signal, Equals, "SIGKILL")
 }
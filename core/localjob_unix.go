@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package core
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setProcessGroup starts the command in its own process group, so the command
+// and all its children can be killed together
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process group of a command started with
+// setProcessGroup
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// exitSignal returns the name of the signal that killed a finished command,
+// empty if it exited by itself
+func exitSignal(cmd *exec.Cmd) string {
+	if cmd.ProcessState == nil {
+		return ""
+	}
+
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	return unix.SignalName(status.Signal())
+}
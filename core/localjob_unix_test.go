@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package core
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *SuiteLocalJob) TestRunSignal(c *C) {
+	job := &LocalJob{}
+	job.Command = `sh -c "kill -TERM $$"`
+
+	e := NewExecution()
+
+	ctx := NewContext(NewScheduler(&TestLogger{}), job, e)
+	err := ctx.Run()
+	c.Assert(err, NotNil)
+	c.Assert(e.Signal, Equals, "SIGTERM")
+	c.Assert(e.ExitCode, Equals, -1)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package core
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the process of the command, on Windows the children
+// are not killed
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	return cmd.Process.Kill()
+}
+
+// exitSignal returns an empty string, on Windows processes are not killed by
+// signals
+func exitSignal(cmd *exec.Cmd) string {
+	return ""
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Events of the execution of a job, logged by the Scheduler
+const (
+	EventStarted  = "started"
+	EventOutput   = "output"
+	EventFinished = "finished"
+)
+
+// Fields are the key/values of a structured log line
+type Fields map[string]interface{}
+
+// FieldLogger is implemented by the structured loggers, the fields are logged
+// as such instead of being formatted into the message
+type FieldLogger interface {
+	Logger
+	WithFields(f Fields) Logger
+}
+
+// WithFields returns a Logger adding the given fields to every line, for the
+// loggers not implementing FieldLogger the fields are appended to the message
+// as key=value pairs
+func WithFields(l Logger, f Fields) Logger {
+	if fl, ok := l.(FieldLogger); ok {
+		return fl.WithFields(f)
+	}
+
+	return &textFieldLogger{l: l, suffix: formatFields(f)}
+}
+
+// fieldsFromKeysAndValues builds Fields from the alternated keys and values
+// used by the cron logger
+func fieldsFromKeysAndValues(keysAndValues []interface{}) Fields {
+	f := make(Fields, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key := fmt.Sprint(keysAndValues[i])
+		if i+1 < len(keysAndValues) {
+			f[key] = keysAndValues[i+1]
+		} else {
+			f[key] = nil
+		}
+	}
+
+	return f
+}
+
+func formatFields(f Fields) string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, f[k]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+type textFieldLogger struct {
+	l      Logger
+	suffix string
+}
+
+func (t *textFieldLogger) format(format string) string {
+	if t.suffix == "" {
+		return format
+	}
+
+	return format + " " + strings.ReplaceAll(t.suffix, "%", "%%")
+}
+
+func (t *textFieldLogger) Criticalf(format string, args ...interface{}) {
+	t.l.Criticalf(t.format(format), args...)
+}
+
+func (t *textFieldLogger) Debugf(format string, args ...interface{}) {
+	t.l.Debugf(t.format(format), args...)
+}
+
+func (t *textFieldLogger) Errorf(format string, args ...interface{}) {
+	t.l.Errorf(t.format(format), args...)
+}
+
+func (t *textFieldLogger) Noticef(format string, args ...interface{}) {
+	t.l.Noticef(t.format(format), args...)
+}
+
+func (t *textFieldLogger) Warningf(format string, args ...interface{}) {
+	t.l.Warningf(t.format(format), args...)
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	// ExecutionIDEnv is set on every process started through an exec, it
+	// allows us to find the processes of an execution inside of a container
+	ExecutionIDEnv = "CHADBURN_EXECUTION_ID"
+
+	// maxRuntimeGrace is how long we wait for a killed workload to return
+	maxRuntimeGrace = 10 * time.Second
+)
+
+// runWithMaxRuntime calls fn, if it doesn't return in the given time, kill is
+// called and ErrMaxTimeRunning is returned. A zero max means no limit.
+func runWithMaxRuntime(max time.Duration, fn func() error, kill func()) error {
+	if max <= 0 {
+		return fn()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	timer := time.NewTimer(max)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	kill()
+
+	select {
+	case <-done:
+	case <-time.After(maxRuntimeGrace):
+	}
+
+	return ErrMaxTimeRunning
+}
+
+// killExec kills every process of the given execution running in the
+// container, Docker doesn't provide a way to signal an exec so the processes
+// are found by the ExecutionIDEnv variable and killed from a new exec. It
+// fails if the container lacks the tools used, eg.: on distroless images.
+func killExec(client DockerClient, container, executionID string) error {
+	script := fmt.Sprintf(
+		`command -v tr >/dev/null && command -v grep >/dev/null || exit 127; `+
+			`for e in /proc/[0-9]*/environ; do `+
+			`if tr '\0' '\n' < "$e" 2>/dev/null | grep -qx '%s=%s'; then `+
+			`p="${e%%/environ}"; kill -9 "${p#/proc/}" 2>/dev/null; `+
+			`fi; done; true`,
+		ExecutionIDEnv, executionID,
+	)
+
+	execID, err := client.CreateExec(container, []string{"sh", "-c", script}, &ExecConfig{
+		AttachStdout: true,
+		AttachStderr: true,
+		User:         "root",
+	})
+	if err != nil {
+		return fmt.Errorf("error creating kill exec: %s", err)
+	}
+
+	reader, err := client.StartExec(execID, true, true)
+	if err != nil {
+		return fmt.Errorf("error starting kill exec: %s", err)
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+		return err
+	}
+
+	inspect, err := client.InspectExec(execID)
+	if err != nil {
+		return fmt.Errorf("error inspecting kill exec: %s", err)
+	}
+
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("kill exec exited with code %d", inspect.ExitCode)
+	}
+
+	return nil
+}
+
+// copyExecOutput copies the output of a started exec to the execution, if the
+// exec runs for longer than max its processes are killed. When they cannot be
+// the container is restarted if restart is true, otherwise the failure is
+// reported on the execution.
+func copyExecOutput(ctx *Context, client DockerClient, container string, reader io.ReadCloser, tty bool, max time.Duration, restart bool) error {
+	return runWithMaxRuntime(max, func() error {
+		return copyOutput(ctx.Execution, reader, tty)
+	}, func() {
+		defer reader.Close()
+
+		err := killExec(client, container, ctx.Execution.ID)
+		if err == nil {
+			ctx.Execution.Signal = "SIGKILL"
+			return
+		}
+
+		if !restart {
+			ctx.Execution.KillError = err.Error()
+			ctx.Logger.Errorf("Cannot kill the processes of execution %s, the command may still be running: %s", ctx.Execution.ID, err)
+			return
+		}
+
+		ctx.Logger.Warningf("Cannot kill the processes of execution %s: %s, restarting container %s", ctx.Execution.ID, err, container)
+		if err := client.RestartContainer(container); err != nil {
+			ctx.Execution.KillError = fmt.Sprintf("error restarting container: %s", err)
+			ctx.Logger.Errorf("Cannot restart container %s, the command of execution %s may still be running: %s", container, ctx.Execution.ID, err)
+			return
+		}
+
+		ctx.Execution.ContainerRestarted = true
+	})
+}
+
+// stopAndRemoveContainer kills a container exceeding the max-runtime
+func stopAndRemoveContainer(ctx *Context, client DockerClient, id string) {
+	if err := client.StopContainer(id); err != nil {
+		ctx.Logger.Warningf("Cannot stop container %s: %s", id, err)
+	}
+
+	if err := client.RemoveContainer(id); err != nil {
+		ctx.Logger.Warningf("Cannot remove container %s: %s", id, err)
+	}
+}
@@ -3,11 +3,14 @@ package core
 import (
 	"context"
 	"io"
+
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
 // MockDockerClient is a mock implementation of the DockerClient interface for testing
 type MockDockerClient struct {
-	// Add fields to track method calls and return values
+	// tty is the TTY setting of the last exec created
+	tty bool
 }
 
 // ListContainers lists containers with the given filters
@@ -42,6 +45,11 @@ func (c *MockDockerClient) StopContainer(id string) error {
 	return nil
 }
 
+// RestartContainer stops and starts again a container
+func (c *MockDockerClient) RestartContainer(id string) error {
+	return nil
+}
+
 // RemoveContainer removes a container
 func (c *MockDockerClient) RemoveContainer(id string) error {
 	return nil
@@ -52,8 +60,22 @@ func (c *MockDockerClient) WaitContainer(id string) (int, error) {
 	return 0, nil
 }
 
+// ContainerLogs returns the multiplexed logs of a container
+func (c *MockDockerClient) ContainerLogs(id string, follow bool) (io.ReadCloser, error) {
+	r, w := io.Pipe()
+
+	go func() {
+		stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte("test output"))
+		stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte("test error"))
+		w.Close()
+	}()
+
+	return r, nil
+}
+
 // CreateExec creates an exec instance in a container
 func (c *MockDockerClient) CreateExec(containerID string, cmd []string, config *ExecConfig) (string, error) {
+	c.tty = config.Tty
 	return "", nil
 }
 
@@ -62,9 +84,16 @@ func (c *MockDockerClient) StartExec(execID string, attachStdout, attachStderr b
 	// Create a pipe to capture the output
 	r, w := io.Pipe()
 
-	// Write some data to the pipe
+	// Write some data to the pipe, multiplexed as Docker does without a TTY
+	tty := c.tty
 	go func() {
-		w.Write([]byte("test output"))
+		if tty {
+			w.Write([]byte("test output"))
+		} else {
+			stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte("test output"))
+			stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte("test error"))
+		}
+
 		w.Close()
 	}()
 
@@ -81,6 +110,11 @@ func (c *MockDockerClient) PullImage(imageName string) error {
 	return nil
 }
 
+// ImageExists returns whether an image is present locally
+func (c *MockDockerClient) ImageExists(imageName string) (bool, error) {
+	return true, nil
+}
+
 // WatchEvents watches Docker events and sends them to the provided channel
 func (c *MockDockerClient) WatchEvents(ctx context.Context, eventCh chan<- *DockerEvent, errCh chan<- error) {
 	// No-op for mock
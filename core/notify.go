@@ -0,0 +1,61 @@
+package core
+
+import (
+	"time"
+)
+
+// NotifyPolicy decides if a finished execution must be reported by the
+// notification middlewares
+type NotifyPolicy interface {
+	// Notify is called once per execution, after the job is finished
+	Notify(ctx *Context) Notification
+}
+
+// Notification is the decision taken by the NotifyPolicy for an execution
+type Notification struct {
+	// Send is false when the execution must not be notified
+	Send bool
+	// Recovery is true when the job succeeded after having failed
+	Recovery bool
+	// Digest is true when the execution is reported only to summarize the
+	// notifications held back by the rate limit in the previous period
+	Digest bool
+	// Suppressed counts the notifications held back by the rate limit since
+	// SuppressedSince, SuppressedFailed the failures among them
+	Suppressed       int
+	SuppressedFailed int
+	SuppressedSince  time.Time
+}
+
+// SetNotifyPolicy sets the policy consulted by the notification middlewares
+func (c *Context) SetNotifyPolicy(p NotifyPolicy) {
+	c.notify = p
+}
+
+// Notification returns the decision of the NotifyPolicy for this execution,
+// the policy is consulted only once, no matter how many middlewares ask. It
+// must be called once the execution is stopped.
+func (c *Context) Notification() Notification {
+	if c.notification == nil {
+		n := Notification{Send: true}
+		if c.notify != nil {
+			n = c.notify.Notify(c)
+		}
+
+		c.notification = &n
+	}
+
+	return *c.notification
+}
+
+// ShouldNotify returns whether a notification middleware must report the
+// execution, onlyOnError is its own only-on-error setting. Recoveries and
+// digests are reported even to the middlewares that only report errors.
+func (c *Context) ShouldNotify(onlyOnError bool) bool {
+	n := c.Notification()
+	if !n.Send {
+		return false
+	}
+
+	return !onlyOnError || c.Execution.Failed() || n.Recovery || n.Digest
+}
@@ -2,8 +2,8 @@ package core
 
 import (
 	"fmt"
-	"io"
 	"reflect"
+	"time"
 
 	"github.com/gobs/args"
 )
@@ -27,23 +27,31 @@ func NewOfficialLifecycleJob(c DockerClient) *OfficialLifecycleJob {
 // Run executes the job
 func (j *OfficialLifecycleJob) Run(ctx *Context) error {
 	// Create variable context
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.Container,
-			ID:   j.Container, // We use the container name as ID for now
-		},
-	}
+	varContext := NewVariableContext(ctx, ContainerInfo{
+		Name: j.Container,
+		ID:   j.Container, // We use the container name as ID for now
+	})
 
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(varContext)
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
+
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
 
 	// Create and start exec
-	execID, err := j.buildExec(processedCommand)
+	execID, err := j.buildExec(processedCommand, ctx.Execution)
 	if err != nil {
 		return err
 	}
 
-	if err := j.startExec(execID, ctx.Execution); err != nil {
+	if err := j.startExec(execID, ctx, max); err != nil {
 		return err
 	}
 
@@ -59,7 +67,7 @@ func (j *OfficialLifecycleJob) Hash() string {
 }
 
 // buildExec creates an exec instance in the container
-func (j *OfficialLifecycleJob) buildExec(processedCommand string) (string, error) {
+func (j *OfficialLifecycleJob) buildExec(processedCommand string, e *Execution) (string, error) {
 	// Check if container is running
 	container, err := j.Client.InspectContainer(j.Container)
 	if err != nil {
@@ -70,6 +78,8 @@ func (j *OfficialLifecycleJob) buildExec(processedCommand string) (string, error
 		return "", fmt.Errorf("unable to exec because container %q is not running", j.Container)
 	}
 
+	e.ContainerID = container.ID
+
 	// Parse command
 	var cmds []string
 	if processedCommand != "" {
@@ -83,6 +93,7 @@ func (j *OfficialLifecycleJob) buildExec(processedCommand string) (string, error
 		AttachStderr: true,
 		Tty:          j.TTY,
 		User:         j.User,
+		Env:          []string{ExecutionIDEnv + "=" + e.ID},
 	}
 
 	// Create exec instance
@@ -91,11 +102,12 @@ func (j *OfficialLifecycleJob) buildExec(processedCommand string) (string, error
 		return "", fmt.Errorf("error creating exec: %s", err)
 	}
 
+	e.ExecID = execID
 	return execID, nil
 }
 
-// startExec starts an exec instance
-func (j *OfficialLifecycleJob) startExec(execID string, e *Execution) error {
+// startExec starts an exec instance, killing it if runs longer than max
+func (j *OfficialLifecycleJob) startExec(execID string, ctx *Context, max time.Duration) error {
 	reader, err := j.Client.StartExec(execID, true, true)
 	if err != nil {
 		return fmt.Errorf("error starting exec: %s", err)
@@ -103,14 +115,7 @@ func (j *OfficialLifecycleJob) startExec(execID string, e *Execution) error {
 	defer reader.Close()
 
 	// Copy output to the execution streams
-	if e.OutputStream != nil {
-		_, err = io.Copy(e.OutputStream, reader)
-		if err != nil {
-			return fmt.Errorf("error copying output: %s", err)
-		}
-	}
-
-	return nil
+	return copyExecOutput(ctx, j.Client, j.Container, reader, j.TTY, max, j.MaxRuntimeRestart)
 }
 
 // inspectExec inspects an exec instance
@@ -121,7 +126,7 @@ func (j *OfficialLifecycleJob) inspectExec(execID string) error {
 	}
 
 	if inspect.ExitCode != 0 {
-		return fmt.Errorf("error non-zero exit code: %d", inspect.ExitCode)
+		return NewExitCodeError(inspect.ExitCode)
 	}
 
 	return nil
@@ -35,21 +35,40 @@ func (j *OfficialRunJob) Run(ctx *Context) error {
 		}
 	}
 
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
 	// Create and start container
 	containerID, err := j.startContainer(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Wait for container to finish
-	exitCode, err := j.Client.WaitContainer(containerID)
+	// Stream the output of the container while it runs
+	waitLogs := streamContainerLogs(ctx, j.Client, containerID, j.TTY)
+
+	// Wait for container to finish, it is stopped and removed if it runs for
+	// longer than max-runtime
+	var exitCode int
+	err = runWithMaxRuntime(max, func() error {
+		var err error
+		exitCode, err = j.Client.WaitContainer(containerID)
+		return err
+	}, func() {
+		stopAndRemoveContainer(ctx, j.Client, containerID)
+	})
+
+	waitLogs()
+
 	if err != nil {
 		return err
 	}
 
 	// Check exit code
 	if exitCode != 0 {
-		return fmt.Errorf("error non-zero exit code: %d", exitCode)
+		return NewExitCodeError(exitCode)
 	}
 
 	// Remove container
@@ -74,12 +93,21 @@ func (j *OfficialRunJob) pullImage() error {
 
 // startContainer creates and starts a container
 func (j *OfficialRunJob) startContainer(ctx *Context) (string, error) {
+	// Get processed command with variables replaced, the container does not
+	// exist yet
+	processedCommand, err := j.GetProcessedCommand(NewVariableContext(ctx, ContainerInfo{Image: j.Image}))
+	if err != nil {
+		return "", err
+	}
+
 	// Parse command
 	var cmds []string
-	if j.Command != "" {
-		cmds = args.GetArgs(j.Command)
+	if processedCommand != "" {
+		cmds = args.GetArgs(processedCommand)
 	}
 
+	ctx.Execution.Command = processedCommand
+
 	// Create container config
 	config := &ContainerConfig{
 		Image:        j.Image,
@@ -103,13 +131,12 @@ func (j *OfficialRunJob) startContainer(ctx *Context) (string, error) {
 		return "", fmt.Errorf("error creating container: %s", err)
 	}
 
+	ctx.Execution.ContainerID = container.ID
+
 	// Start container
 	if err := j.Client.StartContainer(container.ID); err != nil {
 		return container.ID, fmt.Errorf("error starting container: %s", err)
 	}
 
-	// TODO: Implement logs streaming for containers
-	// This would require extending the DockerClient interface with a ContainerLogs method
-
 	return container.ID, nil
 }
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/armon/circbuf"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// outputWriters returns the writers receiving the stdout and the stderr of
+// the command of an execution
+func (e *Execution) outputWriters() (stdout, stderr io.Writer) {
+	return teeWriter(e.OutputStream, e.Stdout), teeWriter(e.ErrorStream, e.Stderr)
+}
+
+func teeWriter(b *circbuf.Buffer, w io.Writer) io.Writer {
+	switch {
+	case b == nil && w == nil:
+		return ioutil.Discard
+	case b == nil:
+		return w
+	case w == nil:
+		return b
+	default:
+		return io.MultiWriter(b, w)
+	}
+}
+
+// copyOutput copies a Docker output stream to the execution buffers. Without
+// a TTY Docker multiplexes stdout and stderr in the same stream, so it is
+// split; with a TTY everything goes to the output buffer as is.
+func copyOutput(e *Execution, r io.Reader, tty bool) error {
+	stdout, stderr := e.outputWriters()
+
+	var err error
+	if tty {
+		_, err = io.Copy(stdout, r)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, r)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error copying output: %s", err)
+	}
+
+	return nil
+}
+
+// streamContainerLogs follows the logs of a started container in the
+// background, the returned function waits until the stream is drained. It
+// must be called once the container is stopped.
+func streamContainerLogs(ctx *Context, client DockerClient, id string, tty bool) func() {
+	reader, err := client.ContainerLogs(id, true)
+	if err != nil {
+		ctx.Logger.Warningf("Cannot read the logs of container %s: %s", id, err)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := copyOutput(ctx.Execution, reader, tty); err != nil {
+			ctx.Logger.Warningf("Cannot read the logs of container %s: %s", id, err)
+		}
+	}()
+
+	return func() {
+		select {
+		case <-done:
+		case <-time.After(maxRuntimeGrace):
+			ctx.Logger.Warningf("Timeout reading the logs of container %s", id)
+		}
+
+		reader.Close()
+		<-done
+	}
+}
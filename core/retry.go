@@ -0,0 +1,73 @@
+package core
+
+import (
+	"time"
+
+	"github.com/armon/circbuf"
+)
+
+// RetryPolicy decides if a failed execution should be retried
+type RetryPolicy interface {
+	// Retry is called after every failed attempt, it returns whether the job
+	// must be run again and how long to wait before doing it
+	Retry(attempt int, err error) (time.Duration, bool)
+}
+
+// Attempt is a single run of the job inside of an execution
+type Attempt struct {
+	Number   int
+	Start    time.Time
+	End      time.Time
+	Err      error
+	ExitCode int
+	// OutputStream and ErrorStream are the output of this attempt, the ones
+	// of the last attempt are the output of the execution
+	OutputStream *circbuf.Buffer
+	ErrorStream  *circbuf.Buffer
+}
+
+// Duration returns how long the attempt took
+func (a *Attempt) Duration() time.Duration {
+	return a.End.Sub(a.Start)
+}
+
+// SetRetryPolicy sets the policy used to retry the job when it fails, the job
+// is retried inside of the same execution so the middlewares wrapping it see
+// only the final outcome
+func (c *Context) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+func (c *Context) runAttempts() error {
+	for number := 1; ; number++ {
+		a := &Attempt{Number: number, Start: time.Now()}
+		c.Execution.Attempt = number
+		c.Execution.Signal = ""
+		c.Execution.ContainerRestarted = false
+		c.Execution.KillError = ""
+
+		err := c.Job.Run(c)
+		a.End = time.Now()
+		a.Err = err
+		a.ExitCode = c.Execution.setExitCode(err)
+		a.OutputStream, a.ErrorStream = c.Execution.OutputStream, c.Execution.ErrorStream
+
+		c.Execution.Attempts = append(c.Execution.Attempts, a)
+		if err == nil || c.retry == nil {
+			return err
+		}
+
+		delay, retry := c.retry.Retry(number, err)
+		if !retry {
+			return err
+		}
+
+		c.Logger.Warningf(
+			"[Job %q (%s)] Attempt %d failed: %s, retrying in %s",
+			c.Job.GetName(), c.Execution.ID, number, err, delay,
+		)
+
+		time.Sleep(delay)
+		c.Execution.resetOutput()
+	}
+}
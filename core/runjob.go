@@ -2,7 +2,6 @@ package core
 
 import (
 	"fmt"
-	"io"
 	"reflect"
 
 	"github.com/gobs/args"
@@ -46,6 +45,11 @@ func (j *RunJob) Hash() string {
 }
 
 func (j *RunJob) startContainer(ctx *Context) error {
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
 	// Check if container exists and is running
 	container, err := j.Client.InspectContainer(j.Container)
 	if err != nil {
@@ -61,15 +65,19 @@ func (j *RunJob) startContainer(ctx *Context) error {
 	}
 
 	// Create variable context
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.Container,
-			ID:   j.Container, // We use the container name as ID for now
-		},
-	}
+	varContext := NewVariableContext(ctx, ContainerInfo{
+		Name: j.Container,
+		ID:   j.Container, // We use the container name as ID for now
+	})
 
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(varContext)
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
+	ctx.Execution.ContainerID = j.Container
 
 	// Create exec config
 	config := &ExecConfig{
@@ -78,6 +86,7 @@ func (j *RunJob) startContainer(ctx *Context) error {
 		AttachStderr: true,
 		Tty:          j.TTY,
 		User:         j.User,
+		Env:          []string{ExecutionIDEnv + "=" + ctx.Execution.ID},
 	}
 
 	// Create exec instance
@@ -86,6 +95,8 @@ func (j *RunJob) startContainer(ctx *Context) error {
 		return fmt.Errorf("error creating exec: %s", err)
 	}
 
+	ctx.Execution.ExecID = execID
+
 	// Start exec
 	reader, err := j.Client.StartExec(execID, true, true)
 	if err != nil {
@@ -94,11 +105,8 @@ func (j *RunJob) startContainer(ctx *Context) error {
 	defer reader.Close()
 
 	// Copy output to the execution streams
-	if ctx.Execution.OutputStream != nil {
-		_, err = io.Copy(ctx.Execution.OutputStream, reader)
-		if err != nil {
-			return fmt.Errorf("error copying output: %s", err)
-		}
+	if err := copyExecOutput(ctx, j.Client, j.Container, reader, j.TTY, max, j.MaxRuntimeRestart); err != nil {
+		return err
 	}
 
 	// Inspect exec
@@ -108,13 +116,27 @@ func (j *RunJob) startContainer(ctx *Context) error {
 	}
 
 	if inspect.ExitCode != 0 {
-		return fmt.Errorf("error non-zero exit code: %d", inspect.ExitCode)
+		return NewExitCodeError(inspect.ExitCode)
 	}
 
 	return nil
 }
 
 func (j *RunJob) runContainer(ctx *Context) error {
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
+	// Get processed command with variables replaced, the container does not
+	// exist yet
+	processedCommand, err := j.GetProcessedCommand(NewVariableContext(ctx, ContainerInfo{Image: j.Image}))
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
+
 	// Pull the image
 	if err := j.Client.PullImage(j.Image); err != nil {
 		return fmt.Errorf("error pulling image: %s", err)
@@ -123,7 +145,7 @@ func (j *RunJob) runContainer(ctx *Context) error {
 	// Create container config
 	config := &ContainerConfig{
 		Image:        j.Image,
-		Cmd:          args.GetArgs(j.GetCommand()),
+		Cmd:          args.GetArgs(processedCommand),
 		AttachStdout: true,
 		AttachStderr: true,
 		Tty:          j.TTY,
@@ -144,14 +166,34 @@ func (j *RunJob) runContainer(ctx *Context) error {
 		return fmt.Errorf("error creating container: %s", err)
 	}
 
+	ctx.Execution.ContainerID = container.ID
+
 	// Start the container
 	err = j.Client.StartContainer(container.ID)
 	if err != nil {
 		return fmt.Errorf("error starting container: %s", err)
 	}
 
-	// Wait for the container to finish
-	exitCode, err := j.Client.WaitContainer(container.ID)
+	// Stream the output of the container while it runs
+	waitLogs := streamContainerLogs(ctx, j.Client, container.ID, j.TTY)
+
+	// Wait for the container to finish, it is stopped and removed if it runs
+	// for longer than max-runtime
+	var exitCode int
+	err = runWithMaxRuntime(max, func() error {
+		var err error
+		exitCode, err = j.Client.WaitContainer(container.ID)
+		return err
+	}, func() {
+		stopAndRemoveContainer(ctx, j.Client, container.ID)
+	})
+
+	waitLogs()
+
+	if err == ErrMaxTimeRunning {
+		return err
+	}
+
 	if err != nil {
 		return fmt.Errorf("error waiting for container: %s", err)
 	}
@@ -165,7 +207,7 @@ func (j *RunJob) runContainer(ctx *Context) error {
 	}
 
 	if exitCode != 0 {
-		return fmt.Errorf("error non-zero exit code: %d", exitCode)
+		return NewExitCodeError(exitCode)
 	}
 
 	return nil
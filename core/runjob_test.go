@@ -1,6 +1,8 @@
 package core
 
 import (
+	"strings"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -26,3 +28,111 @@ func (s *SuiteRunJob) TestRun(c *C) {
 	err := job.Run(&Context{Execution: e})
 	c.Assert(err, IsNil)
 }
+
+func (s *SuiteRunJob) TestRunOutput(c *C) {
+	job := &OfficialRunJob{Client: s.mockClient}
+	job.Image = "test"
+	job.Command = `echo foo`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, IsNil)
+	c.Assert(e.OutputStream.String(), Equals, "test output")
+	c.Assert(e.ErrorStream.String(), Equals, "test error")
+}
+
+func (s *SuiteRunJob) TestRunJobImageOutput(c *C) {
+	job := NewRunJob(s.mockClient)
+	job.Image = "test"
+	job.Command = `echo foo`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, IsNil)
+	c.Assert(e.OutputStream.String(), Equals, "test output")
+	c.Assert(e.ErrorStream.String(), Equals, "test error")
+}
+
+func (s *SuiteRunJob) TestRunJobImageVariables(c *C) {
+	client := &createdContainersClient{}
+
+	job := NewRunJob(client)
+	job.Name = "dump"
+	job.Image = "postgres"
+	job.Command = `pg_dump -f {{.Job.Name}}-{{.Execution.ScheduledTime | date "20060102"}}.sql`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Job: job, Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, IsNil)
+
+	expected := "dump-" + e.ScheduledTime.Format("20060102") + ".sql"
+	c.Assert(e.Command, Equals, "pg_dump -f "+expected)
+	c.Assert(client.configs, HasLen, 1)
+	c.Assert(client.configs[0].Cmd, DeepEquals, []string{"pg_dump", "-f", expected})
+
+	// the invalid variables fail the execution before creating the container
+	job.Command = `pg_dump -f {{.Job.Name | date "2006"}}.sql`
+	err = job.Run(&Context{Job: job, Execution: NewExecution(), Logger: &TestLogger{}})
+	c.Assert(err, ErrorMatches, "error processing the variables of the command: .*")
+	c.Assert(client.configs, HasLen, 1)
+}
+
+func (s *SuiteRunJob) TestCopyOutputTTY(c *C) {
+	e := NewExecution()
+
+	err := copyOutput(e, strings.NewReader("raw output"), true)
+	c.Assert(err, IsNil)
+	c.Assert(e.OutputStream.String(), Equals, "raw output")
+	c.Assert(e.ErrorStream.String(), Equals, "")
+}
+
+func (s *SuiteRunJob) TestRunMaxRuntime(c *C) {
+	client := &blockingDockerClient{stopped: make(chan struct{})}
+
+	job := &OfficialRunJob{Client: client}
+	job.Image = "test"
+	job.Command = `sleep 3600`
+	job.MaxRuntime = "100ms"
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, Equals, ErrMaxTimeRunning)
+	c.Assert(client.removed, Equals, true)
+}
+
+// blockingDockerClient runs containers until they are stopped
+type blockingDockerClient struct {
+	MockDockerClient
+	stopped chan struct{}
+	removed bool
+}
+
+func (c *blockingDockerClient) WaitContainer(id string) (int, error) {
+	<-c.stopped
+	return 137, nil
+}
+
+func (c *blockingDockerClient) StopContainer(id string) error {
+	close(c.stopped)
+	return nil
+}
+
+func (c *blockingDockerClient) RemoveContainer(id string) error {
+	c.removed = true
+	return nil
+}
+
+// createdContainersClient records the configurations of the containers created
+type createdContainersClient struct {
+	MockDockerClient
+	configs []*ContainerConfig
+}
+
+func (c *createdContainersClient) CreateContainer(config *ContainerConfig) (*Container, error) {
+	c.configs = append(c.configs, config)
+	return c.MockDockerClient.CreateContainer(config)
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/gobs/args"
@@ -9,19 +10,22 @@ import (
 
 // Note: The ServiceJob is loosely inspired by https://github.com/alexellis/jaas/
 
+// defaultServiceTimeout is how long we wait for a service without max-runtime
+const defaultServiceTimeout = 10 * time.Minute
+
 // RunServiceJob represents a job that runs a Docker service
 type RunServiceJob struct {
 	BareJob `mapstructure:",squash"`
 	Client  DockerClient `json:"-"`
-	User    string       `default:"root"`
-	TTY     bool         `default:"false"`
+	User    string       `default:"root" hash:"true"`
+	TTY     bool         `default:"false" hash:"true"`
 	// do not use bool values with "default:true" because if
 	// user would set it to "false" explicitly, it still will be
 	// changed to "true" https://github.com/mcuadros/ofelia/issues/135
 	// so lets use strings here as workaround
-	Delete  string `default:"true"`
-	Image   string
-	Network string
+	Delete  string `default:"true" hash:"true"`
+	Image   string `hash:"true"`
+	Network string `hash:"true"`
 }
 
 // NewRunServiceJob creates a new RunServiceJob
@@ -29,7 +33,23 @@ func NewRunServiceJob(c DockerClient) *RunServiceJob {
 	return &RunServiceJob{Client: c}
 }
 
+// Returns a hash of all the job attributes. Used to detect changes
+func (j *RunServiceJob) Hash() string {
+	var hash string
+	getHash(reflect.TypeOf(j).Elem(), reflect.ValueOf(j).Elem(), &hash)
+	return hash
+}
+
 func (j *RunServiceJob) Run(ctx *Context) error {
+	// Get processed command with variables replaced, the service does not
+	// exist yet
+	processedCommand, err := j.GetProcessedCommand(NewVariableContext(ctx, ContainerInfo{Image: j.Image}))
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
+
 	if err := j.pullImage(); err != nil {
 		return err
 	}
@@ -38,7 +58,7 @@ func (j *RunServiceJob) Run(ctx *Context) error {
 	config := &ServiceConfig{
 		Name:  fmt.Sprintf("chadburn-%s", randomID()),
 		Image: j.Image,
-		Cmd:   args.GetArgs(j.GetCommand()),
+		Cmd:   args.GetArgs(processedCommand),
 		Labels: map[string]string{
 			"chadburn.job": j.Name,
 		},
@@ -54,6 +74,10 @@ func (j *RunServiceJob) Run(ctx *Context) error {
 
 	// Watch the service
 	if err := j.watchContainer(ctx, serviceID); err != nil {
+		if err == ErrMaxTimeRunning {
+			j.deleteService(ctx, serviceID)
+		}
+
 		return err
 	}
 
@@ -93,8 +117,18 @@ func (j *RunServiceJob) waitForTasks(ctx *Context, serviceID string) error {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
-	// Set timeout
-	timeout := time.After(10 * time.Minute)
+	// Set timeout, the max-runtime of the job if any
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
+	}
+
+	limit := defaultServiceTimeout
+	if max > 0 {
+		limit = max
+	}
+
+	timeout := time.After(limit)
 
 	for {
 		select {
@@ -109,6 +143,13 @@ func (j *RunServiceJob) waitForTasks(ctx *Context, serviceID string) error {
 			allCompleted := true
 			for _, task := range tasks {
 				ctx.Logger.Debugf("Task %s has status %s", task.ID, task.Status.State)
+				if task.Status.ContainerID != "" {
+					ctx.Execution.ContainerID = task.Status.ContainerID
+				}
+
+				if task.NodeID != "" {
+					ctx.Execution.Host = task.NodeID
+				}
 
 				if task.Status.State != "complete" && task.Status.State != "failed" {
 					allCompleted = false
@@ -116,6 +157,10 @@ func (j *RunServiceJob) waitForTasks(ctx *Context, serviceID string) error {
 
 				// If a task failed, return an error
 				if task.Status.State == "failed" {
+					if task.Status.ExitCode != 0 {
+						return fmt.Errorf("task %s failed: %s: %w", task.ID, task.Status.Err, NewExitCodeError(task.Status.ExitCode))
+					}
+
 					return fmt.Errorf("task %s failed: %s", task.ID, task.Status.Err)
 				}
 			}
@@ -127,6 +172,10 @@ func (j *RunServiceJob) waitForTasks(ctx *Context, serviceID string) error {
 			}
 
 		case <-timeout:
+			if max > 0 {
+				return ErrMaxTimeRunning
+			}
+
 			return fmt.Errorf("timeout waiting for service %s to complete", serviceID)
 		}
 	}
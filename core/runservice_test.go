@@ -1,6 +1,8 @@
 package core
 
 import (
+	"errors"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -18,3 +20,38 @@ func (s *SuiteRunService) TestRun(c *C) {
 	// Skip this test for now
 	c.Skip("Skipping test for now")
 }
+
+func (s *SuiteRunService) TestRunVariables(c *C) {
+	client := &createdServicesClient{}
+
+	job := NewRunServiceJob(client)
+	job.Name = "dump"
+	job.Image = "postgres"
+	job.Command = `pg_dump -f {{.Job.Name}}-{{.Execution.ID}}.sql`
+
+	e := NewExecution()
+
+	err := job.Run(&Context{Job: job, Execution: e, Logger: &TestLogger{}})
+	c.Assert(err, ErrorMatches, "error creating service: stopped by the test")
+	c.Assert(e.Command, Equals, "pg_dump -f dump-"+e.ID+".sql")
+	c.Assert(client.configs, HasLen, 1)
+	c.Assert(client.configs[0].Cmd, DeepEquals, []string{"pg_dump", "-f", "dump-" + e.ID + ".sql"})
+
+	// the invalid variables fail the execution before creating the service
+	job.Command = `pg_dump -f {{.Job.Name | date "2006"}}.sql`
+	err = job.Run(&Context{Job: job, Execution: NewExecution(), Logger: &TestLogger{}})
+	c.Assert(err, ErrorMatches, "error processing the variables of the command: .*")
+	c.Assert(client.configs, HasLen, 1)
+}
+
+// createdServicesClient records the configurations of the services created,
+// failing their creation
+type createdServicesClient struct {
+	MockDockerClient
+	configs []*ServiceConfig
+}
+
+func (c *createdServicesClient) CreateService(config *ServiceConfig) (string, error) {
+	c.configs = append(c.configs, config)
+	return "", errors.New("stopped by the test")
+}
@@ -3,7 +3,10 @@ package core
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -13,6 +16,7 @@ import (
 var (
 	ErrEmptyScheduler = errors.New("unable to start a empty scheduler.")
 	ErrEmptySchedule  = errors.New("unable to add a job with a empty schedule.")
+	ErrJobNotFound    = errors.New("unable to find a job with the given name.")
 )
 
 var (
@@ -38,6 +42,13 @@ var (
 		},
 		[]string{"job_name"},
 	)
+	RunExitCodesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chadburn_run_exit_codes_total",
+			Help: "Total number of completed job runs by exit code.",
+		},
+		[]string{"job_name", "exit_code"},
+	)
 	RunLatest = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "chadburn_run_latest_timestamp",
@@ -54,37 +65,160 @@ var (
 	)
 )
 
+// SchedulerOptions configures how the schedules of the jobs are interpreted
+type SchedulerOptions struct {
+	// Location is the time zone of the schedules without an explicit one,
+	// the local time zone is used if nil
+	Location *time.Location
+	// Seconds enables an optional sixth field at the beginning of the
+	// schedules with the seconds
+	Seconds bool
+}
+
 type Scheduler struct {
 	Jobs   []Job
 	Logger Logger
 
 	middlewareContainer
 	cron      *cron.Cron
+	parser    cron.Parser
+	location  *time.Location
 	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+	paused    map[Job]bool
 	isRunning bool
+	// satisfied are the upstream jobs that succeeded since the last
+	// triggered execution of a depends-on job, by the name of the job
+	satisfied map[string]map[string]bool
 }
 
 func NewScheduler(l Logger) *Scheduler {
+	return NewSchedulerWithOptions(l, SchedulerOptions{})
+}
+
+// NewSchedulerWithOptions returns a Scheduler interpreting the schedules with
+// the given options
+func NewSchedulerWithOptions(l Logger, o SchedulerOptions) *Scheduler {
+	fields := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+	if o.Seconds {
+		fields |= cron.SecondOptional
+	}
+
+	parser := cron.NewParser(fields)
+	location := o.Location
+	if location == nil {
+		location = time.Local
+	}
+
 	cronUtils := NewCronUtils(l)
 	return &Scheduler{
 		Logger: l,
-		cron:   cron.New(cron.WithLogger(cronUtils), cron.WithChain(cron.Recover(cronUtils))),
+		cron: cron.New(
+			cron.WithLogger(cronUtils),
+			cron.WithChain(cron.Recover(cronUtils)),
+			cron.WithParser(parser),
+			cron.WithLocation(location),
+		),
+		parser:    parser,
+		location:  location,
+		paused:    make(map[Job]bool),
+		satisfied: make(map[string]map[string]bool),
 	}
 }
 
-func (s *Scheduler) AddJob(j Job) error {
+// ValidateJob returns an error if the job cannot be added to the scheduler
+func (s *Scheduler) ValidateJob(j Job) error {
+	if j.GetSchedule() == "" && !IsTriggered(j) {
+		return ErrEmptySchedule
+	}
+
+	if _, err := j.GetMaxRuntime(); err != nil {
+		return fmt.Errorf("invalid max-runtime: %s", err)
+	}
+
+	return s.ValidateSchedule(j)
+}
+
+// ValidateSchedule returns an error if the schedule of the job cannot be
+// parsed, the jobs triggered by other jobs have none
+func (s *Scheduler) ValidateSchedule(j Job) error {
+	if IsTriggered(j) {
+		return nil
+	}
+
 	if j.GetSchedule() == "" {
-		JobRegisterErrorsTotal.Inc()
 		return ErrEmptySchedule
 	}
 
-	id, err := s.cron.AddJob(j.GetSchedule(), &jobWrapper{s, j})
+	_, err := s.parser.Parse(scheduleSpec(j))
+	return err
+}
+
+// Location returns the time zone of the schedules without one
+func (s *Scheduler) Location() *time.Location {
+	return s.location
+}
+
+// NextTimes returns the next n times the job is scheduled to run after the
+// given time, nil for the jobs triggered by other jobs
+func (s *Scheduler) NextTimes(j Job, from time.Time, n int) ([]time.Time, error) {
+	if IsTriggered(j) {
+		return nil, nil
+	}
+
+	schedule, err := s.parser.Parse(scheduleSpec(j))
 	if err != nil {
+		return nil, err
+	}
+
+	var times []time.Time
+	next := from.In(s.location)
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+
+		times = append(times, next)
+	}
+
+	return times, nil
+}
+
+// scheduleSpec returns the schedule of the job with its time zone, a time
+// zone set in the schedule with CRON_TZ= or TZ= takes precedence
+func scheduleSpec(j Job) string {
+	schedule := j.GetSchedule()
+	if j.GetTimezone() == "" || strings.HasPrefix(schedule, "CRON_TZ=") || strings.HasPrefix(schedule, "TZ=") {
+		return schedule
+	}
+
+	return "CRON_TZ=" + j.GetTimezone() + " " + schedule
+}
+
+func (s *Scheduler) AddJob(j Job) error {
+	if err := s.ValidateJob(j); err != nil {
 		JobRegisterErrorsTotal.Inc()
 		return err
 	}
+
+	// triggered jobs are not added to cron, they are run by triggerDependents
+	var id cron.EntryID
+	if !IsTriggered(j) {
+		var err error
+		id, err = s.cron.AddJob(scheduleSpec(j), &jobWrapper{s, j})
+		if err != nil {
+			JobRegisterErrorsTotal.Inc()
+			return err
+		}
+	}
 	j.SetCronJobID(int(id)) // Cast to int in order to avoid pushing cron external to common
 	j.Use(s.Middlewares()...)
+
+	s.mutex.Lock()
+	s.Jobs = append(s.Jobs, j)
+	s.mutex.Unlock()
+
 	SchedulerJobs.Inc()
 	s.Logger.Noticef("New job registered %q - %q - %q - ID: %v", j.GetName(), j.GetCommand(), j.GetSchedule(), id)
 	return nil
@@ -93,10 +227,157 @@ func (s *Scheduler) AddJob(j Job) error {
 func (s *Scheduler) RemoveJob(j Job) error {
 	s.Logger.Noticef("Job deregistered (will not fire again) %q - %q - %q - ID: %v", j.GetName(), j.GetCommand(), j.GetSchedule(), j.GetCronJobID())
 	s.cron.Remove(cron.EntryID(j.GetCronJobID()))
-	SchedulerJobs.Dec()
+
+	s.mutex.Lock()
+	registered := false
+	for i, job := range s.Jobs {
+		if job == j {
+			s.Jobs = append(s.Jobs[:i], s.Jobs[i+1:]...)
+			registered = true
+			break
+		}
+	}
+	delete(s.paused, j)
+	if registered {
+		delete(s.satisfied, j.GetName())
+	}
+	s.mutex.Unlock()
+
+	// a job removed twice is counted once
+	if registered {
+		SchedulerJobs.Dec()
+	}
+
+	return nil
+}
+
+// ReplaceJob removes a registered job and adds its new version, the upstream
+// jobs of depends-on that already succeeded are kept
+func (s *Scheduler) ReplaceJob(old, j Job) error {
+	s.mutex.Lock()
+	satisfied := s.satisfied[old.GetName()]
+	s.mutex.Unlock()
+
+	s.RemoveJob(old)
+	if err := s.AddJob(j); err != nil {
+		return err
+	}
+
+	if satisfied != nil && j.GetName() == old.GetName() {
+		s.mutex.Lock()
+		s.satisfied[j.GetName()] = satisfied
+		s.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// GetJob returns the registered job with the given name, or nil if there is none
+func (s *Scheduler) GetJob(name string) Job {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, j := range s.Jobs {
+		if j.GetName() == name {
+			return j
+		}
+	}
+
+	return nil
+}
+
+// GetJobs returns a snapshot of all the registered jobs
+func (s *Scheduler) GetJobs() []Job {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	jobs := make([]Job, len(s.Jobs))
+	copy(jobs, s.Jobs)
+	return jobs
+}
+
+// RunJob triggers an execution of the given job outside of its schedule, the
+// job runs in background and the returned execution can be used to track it
+func (s *Scheduler) RunJob(j Job) *Execution {
+	e := NewExecution()
+	w := &jobWrapper{s, j}
+	go w.run(e)
+
+	return e
+}
+
+// ExecuteJob runs an execution of a job in the foreground, with the
+// middlewares of the scheduler, and returns once it is finished. The job
+// doesn't need to be registered, eg. to run a job once from the command line,
+// and the jobs depending on it are not triggered.
+func (s *Scheduler) ExecuteJob(j Job, e *Execution) {
+	j.Use(s.Middlewares()...)
+
+	w := &jobWrapper{s, j}
+	w.execute(e)
+}
+
+// PauseJob stops a job from firing on its schedule or being triggered by other
+// jobs, the job stays registered and can still be triggered manually
+func (s *Scheduler) PauseJob(j Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.paused[j] {
+		return nil
+	}
+
+	s.cron.Remove(cron.EntryID(j.GetCronJobID()))
+	s.paused[j] = true
+	s.Logger.Noticef("Job paused %q - ID: %v", j.GetName(), j.GetCronJobID())
 	return nil
 }
 
+// ResumeJob schedules again a job previously paused with PauseJob
+func (s *Scheduler) ResumeJob(j Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.paused[j] {
+		return nil
+	}
+
+	if IsTriggered(j) {
+		delete(s.paused, j)
+		s.Logger.Noticef("Job resumed %q", j.GetName())
+		return nil
+	}
+
+	id, err := s.cron.AddJob(scheduleSpec(j), &jobWrapper{s, j})
+	if err != nil {
+		return err
+	}
+
+	j.SetCronJobID(int(id))
+	delete(s.paused, j)
+	s.Logger.Noticef("Job resumed %q - ID: %v", j.GetName(), id)
+	return nil
+}
+
+// IsPaused returns true if the given job was paused with PauseJob
+func (s *Scheduler) IsPaused(j Job) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.paused[j]
+}
+
+// GetJobTimes returns the next and the previous fire times of a job, both are
+// zero if the job is paused or the scheduler did not compute them yet
+func (s *Scheduler) GetJobTimes(j Job) (next, prev time.Time) {
+	if s.IsPaused(j) {
+		return
+	}
+
+	e := s.cron.Entry(cron.EntryID(j.GetCronJobID()))
+	return e.Next, e.Prev
+}
+
 func (s *Scheduler) Start() error {
 	s.Logger.Debugf("Starting scheduler")
 	s.isRunning = true
@@ -122,21 +403,32 @@ type jobWrapper struct {
 }
 
 func (w *jobWrapper) Run() {
-	ctx := &Context{
-		Scheduler:   w.s,
-		Job:         w.j,
-		Logger:      w.s.Logger,
-		Execution:   NewExecution(),
-		middlewares: w.s.Middlewares(),
+	e := NewExecution()
+	if prev := w.s.cron.Entry(cron.EntryID(w.j.GetCronJobID())).Prev; !prev.IsZero() {
+		e.ScheduledTime = prev
 	}
 
+	w.run(e)
+}
+
+// run executes the job and then triggers the jobs depending on it
+func (w *jobWrapper) run(e *Execution) {
+	w.execute(e)
+	w.s.triggerDependents(w.j, e)
+}
+
+func (w *jobWrapper) execute(e *Execution) {
+	// the middlewares of the job include the ones of the scheduler, added by
+	// AddJob and ExecuteJob
+	ctx := NewContext(w.s, w.j, e)
+
 	w.start(ctx)
 	err := ctx.Run()
 	w.stop(ctx, err)
 }
 
 func (w *jobWrapper) start(ctx *Context) {
-	ctx.Log("Started - " + ctx.Job.GetCommand())
+	ctx.LogEvent(EventStarted, "Started - "+ctx.Job.GetCommand(), Fields{"command": ctx.Job.GetCommand()})
 }
 
 func (w *jobWrapper) stop(ctx *Context, err error) {
@@ -150,7 +442,7 @@ func (w *jobWrapper) stop(ctx *Context, err error) {
 	output := ctx.Execution.OutputStream.Bytes()
 
 	if len(output) > 0 {
-		ctx.Log("Output: " + string(output))
+		ctx.LogEvent(EventOutput, "Output: "+string(output), nil)
 	}
 
 	msg := fmt.Sprintf(
@@ -162,8 +454,25 @@ func (w *jobWrapper) stop(ctx *Context, err error) {
 	if ctx.Execution.Failed() {
 		RunErrorsTotal.WithLabelValues(ctx.Job.GetName()).Inc()
 	}
+	if !ctx.Execution.Skipped() {
+		RunExitCodesTotal.WithLabelValues(ctx.Job.GetName(), strconv.Itoa(ctx.Execution.ExitCode)).Inc()
+	}
 	RunLatest.WithLabelValues(ctx.Job.GetName()).SetToCurrentTime()
 	RunDuration.WithLabelValues(ctx.Job.GetName()).Observe(ctx.Execution.Duration().Seconds())
 
-	ctx.Log(msg)
+	fields := Fields{
+		"duration": ctx.Execution.Duration().Seconds(),
+		"failed":   ctx.Execution.Failed(),
+		"skipped":  ctx.Execution.Skipped(),
+	}
+
+	if ctx.Execution.Error() != nil {
+		fields["error"] = errText
+	}
+
+	if !ctx.Execution.Skipped() {
+		fields["exit_code"] = ctx.Execution.ExitCode
+	}
+
+	ctx.LogEvent(EventFinished, msg, fields)
 }
@@ -23,6 +23,17 @@ func (s *SuiteScheduler) TestAddJob(c *C) {
 	c.Assert(e[0].Job.(*jobWrapper).j, DeepEquals, job)
 }
 
+func (s *SuiteScheduler) TestAddJobInvalidMaxRuntime(c *C) {
+	job := &TestJob{}
+	job.Schedule = "@hourly"
+	job.MaxRuntime = "foo"
+
+	sc := NewScheduler(&TestLogger{})
+	err := sc.AddJob(job)
+	c.Assert(err, NotNil)
+	c.Assert(sc.cron.Entries(), HasLen, 0)
+}
+
 func (s *SuiteScheduler) TestStartStop(c *C) {
 	job := &TestJob{}
 	job.Schedule = "@every 1s"
@@ -55,3 +66,194 @@ func (s *SuiteScheduler) TestMergeMiddlewaresSame(c *C) {
 	c.Assert(m, HasLen, 1)
 	c.Assert(m[0], Equals, mB)
 }
+
+func (s *SuiteScheduler) TestRunMiddlewares(c *C) {
+	mA, mB := &TestMiddlewareAltA{}, &TestMiddlewareAltB{}
+	mA.Nested, mB.Nested = true, true
+
+	job := &TestJob{}
+	job.Schedule = "@hourly"
+	job.Use(mB)
+
+	sc := NewScheduler(&TestLogger{})
+	sc.Use(mA)
+	c.Assert(sc.AddJob(job), IsNil)
+
+	sc.cron.Entries()[0].Job.Run()
+	c.Assert(job.Called, Equals, 1)
+	c.Assert(mA.Called, Equals, 1)
+	c.Assert(mB.Called, Equals, 1)
+}
+
+func (s *SuiteScheduler) TestRemoveJob(c *C) {
+	job := &TestJob{}
+	job.Name = "foo"
+	job.Schedule = "@hourly"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+	c.Assert(sc.GetJob("foo"), Equals, job)
+
+	c.Assert(sc.RemoveJob(job), IsNil)
+	c.Assert(sc.GetJob("foo"), IsNil)
+	c.Assert(sc.GetJobs(), HasLen, 0)
+	c.Assert(sc.cron.Entries(), HasLen, 0)
+}
+
+func (s *SuiteScheduler) TestPauseResumeJob(c *C) {
+	job := &TestJob{}
+	job.Name = "foo"
+	job.Schedule = "@hourly"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	c.Assert(sc.PauseJob(job), IsNil)
+	c.Assert(sc.IsPaused(job), Equals, true)
+	c.Assert(sc.cron.Entries(), HasLen, 0)
+	c.Assert(sc.GetJobs(), HasLen, 1)
+
+	next, prev := sc.GetJobTimes(job)
+	c.Assert(next.IsZero(), Equals, true)
+	c.Assert(prev.IsZero(), Equals, true)
+
+	c.Assert(sc.ResumeJob(job), IsNil)
+	c.Assert(sc.IsPaused(job), Equals, false)
+
+	e := sc.cron.Entries()
+	c.Assert(e, HasLen, 1)
+	c.Assert(int(e[0].ID), Equals, job.GetCronJobID())
+}
+
+func (s *SuiteScheduler) TestRunJob(c *C) {
+	job := &TestJob{}
+	job.Name = "foo"
+	job.Schedule = "@hourly"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	e := sc.RunJob(job)
+	c.Assert(e.ID, Not(Equals), "")
+
+	time.Sleep(time.Second)
+	c.Assert(job.Called, Equals, 1)
+	c.Assert(e.IsRunning(), Equals, false)
+}
+
+func (s *SuiteScheduler) TestExecuteJob(c *C) {
+	job := &TestJob{}
+	job.Name = "foo"
+
+	sc := NewScheduler(&TestLogger{})
+	m := &TestMiddleware{Nested: true}
+	sc.Use(m)
+
+	e := NewExecution()
+	sc.ExecuteJob(job, e)
+
+	c.Assert(job.Called, Equals, 1)
+	c.Assert(m.Called, Equals, 1)
+	c.Assert(e.IsRunning(), Equals, false)
+	c.Assert(sc.GetJob("foo"), IsNil)
+}
+
+func (s *SuiteScheduler) TestAddJobTimezone(c *C) {
+	job := &TestJob{}
+	job.Schedule = "0 2 * * *"
+	job.Timezone = "Asia/Tokyo"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	times, err := sc.NextTimes(job, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	c.Assert(err, IsNil)
+	c.Assert(times[0].UTC(), Equals, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC))
+}
+
+func (s *SuiteScheduler) TestAddJobTimezoneInSchedule(c *C) {
+	job := &TestJob{}
+	job.Schedule = "CRON_TZ=Asia/Tokyo 0 2 * * *"
+	job.Timezone = "Europe/Madrid"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	times, err := sc.NextTimes(job, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	c.Assert(err, IsNil)
+	c.Assert(times[0].UTC(), Equals, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC))
+}
+
+func (s *SuiteScheduler) TestAddJobInvalidTimezone(c *C) {
+	job := &TestJob{}
+	job.Schedule = "@daily"
+	job.Timezone = "Mars/Olympus_Mons"
+
+	sc := NewScheduler(&TestLogger{})
+	c.Assert(sc.AddJob(job), NotNil)
+	c.Assert(sc.cron.Entries(), HasLen, 0)
+}
+
+func (s *SuiteScheduler) TestSchedulerLocation(c *C) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	c.Assert(err, IsNil)
+
+	job := &TestJob{}
+	job.Schedule = "0 2 * * *"
+
+	sc := NewSchedulerWithOptions(&TestLogger{}, SchedulerOptions{Location: tokyo})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	times, err := sc.NextTimes(job, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	c.Assert(err, IsNil)
+	c.Assert(times[0].UTC(), Equals, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC))
+}
+
+func (s *SuiteScheduler) TestSeconds(c *C) {
+	job := &TestJob{}
+	job.Schedule = "*/15 * * * * *"
+
+	c.Assert(NewScheduler(&TestLogger{}).AddJob(job), NotNil)
+
+	sc := NewSchedulerWithOptions(&TestLogger{}, SchedulerOptions{Seconds: true, Location: time.UTC})
+	c.Assert(sc.AddJob(job), IsNil)
+
+	times, err := sc.NextTimes(job, time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC), 2)
+	c.Assert(err, IsNil)
+	c.Assert(times, DeepEquals, []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 15, 0, time.UTC),
+		time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC),
+	})
+
+	// the seconds field is optional, five fields schedules keep working
+	minutely := &TestJob{}
+	minutely.Schedule = "* * * * *"
+	c.Assert(sc.AddJob(minutely), IsNil)
+}
+
+// On the day the clocks go forward the times that don't exist are skipped
+func (s *SuiteScheduler) TestDSTSpringForward(c *C) {
+	job := &TestJob{}
+	job.Schedule = "30 2 * * *"
+	job.Timezone = "America/New_York"
+
+	sc := NewScheduler(&TestLogger{})
+	times, err := sc.NextTimes(job, time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC), 2)
+	c.Assert(err, IsNil)
+	c.Assert(times[0].UTC(), Equals, time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC))
+	c.Assert(times[1].UTC(), Equals, time.Date(2024, 3, 12, 6, 30, 0, 0, time.UTC))
+}
+
+// On the day the clocks go back the times that happen twice run twice
+func (s *SuiteScheduler) TestDSTFallBack(c *C) {
+	job := &TestJob{}
+	job.Schedule = "30 1 * * *"
+	job.Timezone = "America/New_York"
+
+	sc := NewScheduler(&TestLogger{})
+	times, err := sc.NextTimes(job, time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC), 3)
+	c.Assert(err, IsNil)
+	c.Assert(times[0].UTC(), Equals, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC))
+	c.Assert(times[1].UTC(), Equals, time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC))
+	c.Assert(times[2].UTC(), Equals, time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC))
+}
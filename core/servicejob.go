@@ -2,7 +2,6 @@ package core
 
 import (
 	"fmt"
-	"io"
 
 	"github.com/gobs/args"
 )
@@ -22,16 +21,24 @@ func NewServiceJob(c DockerClient) *ServiceJob {
 }
 
 func (j *ServiceJob) Run(ctx *Context) error {
-	// Create variable context
-	varContext := VariableContext{
-		Container: ContainerInfo{
-			Name: j.Container,
-			ID:   j.Container, // We use the container name as ID for now
-		},
+	max, err := j.GetMaxRuntime()
+	if err != nil {
+		return err
 	}
 
+	// Create variable context
+	varContext := NewVariableContext(ctx, ContainerInfo{
+		Name: j.Container,
+		ID:   j.Container, // We use the container name as ID for now
+	})
+
 	// Get processed command with variables replaced
-	processedCommand := j.GetProcessedCommand(varContext)
+	processedCommand, err := j.GetProcessedCommand(varContext)
+	if err != nil {
+		return err
+	}
+
+	ctx.Execution.Command = processedCommand
 
 	// Check if container exists and is running
 	container, err := j.Client.InspectContainer(j.Container)
@@ -43,6 +50,8 @@ func (j *ServiceJob) Run(ctx *Context) error {
 		return fmt.Errorf("unable to exec because container %q is not running", j.Container)
 	}
 
+	ctx.Execution.ContainerID = container.ID
+
 	// Create exec config
 	config := &ExecConfig{
 		AttachStdin:  false,
@@ -50,6 +59,7 @@ func (j *ServiceJob) Run(ctx *Context) error {
 		AttachStderr: true,
 		Tty:          j.TTY,
 		User:         j.User,
+		Env:          []string{ExecutionIDEnv + "=" + ctx.Execution.ID},
 	}
 
 	// Create exec instance
@@ -58,6 +68,8 @@ func (j *ServiceJob) Run(ctx *Context) error {
 		return fmt.Errorf("error creating exec: %s", err)
 	}
 
+	ctx.Execution.ExecID = execID
+
 	// Start exec
 	reader, err := j.Client.StartExec(execID, true, true)
 	if err != nil {
@@ -66,11 +78,8 @@ func (j *ServiceJob) Run(ctx *Context) error {
 	defer reader.Close()
 
 	// Copy output to the execution streams
-	if ctx.Execution.OutputStream != nil {
-		_, err = io.Copy(ctx.Execution.OutputStream, reader)
-		if err != nil {
-			return fmt.Errorf("error copying output: %s", err)
-		}
+	if err := copyExecOutput(ctx, j.Client, j.Container, reader, j.TTY, max, j.MaxRuntimeRestart); err != nil {
+		return err
 	}
 
 	// Inspect exec
@@ -80,13 +89,13 @@ func (j *ServiceJob) Run(ctx *Context) error {
 	}
 
 	if inspect.ExitCode != 0 {
-		return fmt.Errorf("error non-zero exit code: %d", inspect.ExitCode)
+		return NewExitCodeError(inspect.ExitCode)
 	}
 
 	return nil
 }
 
-func (j *ServiceJob) GetProcessedCommand(varContext VariableContext) string {
+func (j *ServiceJob) GetProcessedCommand(varContext VariableContext) (string, error) {
 	// Use the BareJob's implementation
 	return j.BareJob.GetProcessedCommand(varContext)
 }
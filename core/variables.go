@@ -2,28 +2,179 @@ package core
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
+)
+
+// The labels set by Docker Compose on the containers of a service
+const (
+	ComposeProjectLabel = "com.docker.compose.project"
+	ComposeServiceLabel = "com.docker.compose.service"
 )
 
 // ContainerInfo holds information about a container that can be used in variable replacements
 type ContainerInfo struct {
-	Name string
-	ID   string
+	Name   string
+	ID     string
+	Image  string
+	IP     string
+	Labels map[string]string
+}
+
+// NewContainerInfo returns the variables of a container
+func NewContainerInfo(c *Container) ContainerInfo {
+	return ContainerInfo{
+		Name:   c.Name,
+		ID:     c.ID,
+		Image:  c.Image,
+		IP:     c.IP,
+		Labels: c.Labels,
+	}
+}
+
+// ComposeInfo holds the Docker Compose project and service of a container,
+// empty for the containers not created by Compose
+type ComposeInfo struct {
+	Project string
+	Service string
+}
+
+// UpstreamInfo holds information about the execution of another job that
+// triggered the current one, see Dependencies
+type UpstreamInfo struct {
+	Job         string
+	ExecutionID string
+	Failed      bool
+}
+
+// JobInfo holds information about the job being executed
+type JobInfo struct {
+	Name     string
+	Schedule string
+}
+
+// ExecutionInfo holds information about the current execution of a job
+type ExecutionInfo struct {
+	ID string
+	// ScheduledTime is the time the execution was scheduled for, the time it
+	// was requested for the executions triggered manually or by other jobs
+	ScheduledTime time.Time
 }
 
 // VariableContext holds all the variables that can be used in replacements
 type VariableContext struct {
 	Container ContainerInfo
+	Compose   ComposeInfo
+	Upstream  UpstreamInfo
+	Job       JobInfo
+	Execution ExecutionInfo
+	// Now is the time the command was processed, eg.: {{.Now | date "20060102"}}
+	Now time.Time
+}
+
+// NewVariableContext returns the variables available to the given execution
+func NewVariableContext(ctx *Context, container ContainerInfo) VariableContext {
+	vc := VariableContext{
+		Container: container,
+		Compose: ComposeInfo{
+			Project: container.Labels[ComposeProjectLabel],
+			Service: container.Labels[ComposeServiceLabel],
+		},
+		Now: time.Now(),
+	}
+	if ctx != nil && ctx.Job != nil {
+		vc.Job = JobInfo{Name: ctx.Job.GetName(), Schedule: ctx.Job.GetSchedule()}
+	}
+
+	if ctx != nil && ctx.Execution != nil {
+		vc.Upstream = ctx.Execution.Upstream
+		vc.Execution = ExecutionInfo{
+			ID:            ctx.Execution.ID,
+			ScheduledTime: ctx.Execution.ScheduledTime,
+		}
+	}
+
+	return vc
+}
+
+// templateFuncs are the functions available in the commands, named and with
+// the arguments of their Sprig equivalents so they can be piped, eg.:
+// {{.Compose.Service | upper}}
+var templateFuncs = template.FuncMap{
+	"date":       formatDate,
+	"now":        time.Now,
+	"env":        os.Getenv,
+	"default":    defaultValue,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+	"trunc":      truncate,
+	"quote":      func(s string) string { return strconv.Quote(s) },
+	"squote":     func(s string) string { return "'" + s + "'" },
+	"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+}
+
+// formatDate formats a time, or a Unix timestamp, with a Go layout
+func formatDate(layout string, date interface{}) (string, error) {
+	switch t := date.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		return t.Format(layout), nil
+	case int:
+		return time.Unix(int64(t), 0).Format(layout), nil
+	case int64:
+		return time.Unix(t, 0).Format(layout), nil
+	default:
+		return "", fmt.Errorf("date: unsupported value %v of type %T", date, date)
+	}
+}
+
+// defaultValue returns value, or def if value is empty
+func defaultValue(def, value interface{}) interface{} {
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return def
+	}
+
+	return value
+}
+
+// truncate returns the first n characters of s, the last ones if n is
+// negative
+func truncate(n int, s string) string {
+	r := []rune(s)
+	switch {
+	case n >= 0 && n < len(r):
+		return string(r[:n])
+	case n < 0 && -n < len(r):
+		return string(r[len(r)+n:])
+	default:
+		return s
+	}
 }
 
 // ProcessVariables replaces variables in the input string using the provided context
 func ProcessVariables(input string, context VariableContext) (string, error) {
 	// If the input doesn't contain any template markers, return it as is
-	if !bytes.Contains([]byte(input), []byte("{{")) {
+	if !strings.Contains(input, "{{") {
 		return input, nil
 	}
 
-	tmpl, err := template.New("command").Parse(input)
+	tmpl, err := template.New("command").Funcs(templateFuncs).Parse(input)
 	if err != nil {
 		return input, err
 	}
@@ -36,3 +187,11 @@ func ProcessVariables(input string, context VariableContext) (string, error) {
 
 	return buf.String(), nil
 }
+
+// CheckVariables returns the errors of the variables of a command, processing
+// them with empty values, eg. a string argument whose quotes were removed by
+// the INI parser
+func CheckVariables(input string) error {
+	_, err := ProcessVariables(input, NewVariableContext(nil, ContainerInfo{}))
+	return err
+}
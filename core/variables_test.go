@@ -1,10 +1,15 @@
 package core
 
 import (
+	"os"
 	"testing"
+	"time"
 )
 
 func TestProcessVariables(t *testing.T) {
+	os.Setenv("CHADBURN_TEST_VARIABLE", "foo")
+	defer os.Unsetenv("CHADBURN_TEST_VARIABLE")
+
 	tests := []struct {
 		name     string
 		input    string
@@ -55,6 +60,70 @@ func TestProcessVariables(t *testing.T) {
 			expected: "echo Container test-container has ID abc123",
 			hasError: false,
 		},
+		{
+			name:  "Upstream execution",
+			input: "echo {{.Upstream.Job}} {{.Upstream.ExecutionID}}",
+			context: VariableContext{
+				Upstream: UpstreamInfo{Job: "dump", ExecutionID: "abc123"},
+			},
+			expected: "echo dump abc123",
+			hasError: false,
+		},
+		{
+			name:  "Compose and container details",
+			input: "echo {{.Compose.Project}}-{{.Compose.Service}} {{.Container.Image}} {{.Container.IP}} {{index .Container.Labels \"tier\"}}",
+			context: VariableContext{
+				Container: ContainerInfo{
+					Image:  "postgres:16",
+					IP:     "172.18.0.2",
+					Labels: map[string]string{"tier": "db"},
+				},
+				Compose: ComposeInfo{Project: "shop", Service: "db"},
+			},
+			expected: "echo shop-db postgres:16 172.18.0.2 db",
+			hasError: false,
+		},
+		{
+			name:  "Job and execution",
+			input: "echo {{.Job.Name}} {{.Execution.ID}} {{.Execution.ScheduledTime | date \"2006-01-02 15:04\"}}",
+			context: VariableContext{
+				Job:       JobInfo{Name: "dump"},
+				Execution: ExecutionInfo{ID: "abc123", ScheduledTime: time.Date(2025, 3, 16, 3, 0, 0, 0, time.UTC)},
+			},
+			expected: "echo dump abc123 2025-03-16 03:00",
+			hasError: false,
+		},
+		{
+			name:     "Date of now",
+			input:    `pg_dump -f dump-{{.Now | date "20060102"}}.sql`,
+			context:  VariableContext{Now: time.Date(2025, 3, 16, 3, 0, 0, 0, time.UTC)},
+			expected: "pg_dump -f dump-20250316.sql",
+			hasError: false,
+		},
+		{
+			name:     "Environment",
+			input:    `echo {{env "CHADBURN_TEST_VARIABLE"}} {{env "CHADBURN_TEST_MISSING" | default "none"}}`,
+			context:  VariableContext{},
+			expected: "echo foo none",
+			hasError: false,
+		},
+		{
+			name:  "String functions",
+			input: `echo {{.Compose.Service | upper}} {{.Compose.Project | replace "-" "_" | quote}} {{trunc 3 .Container.ID}} {{splitList "," "a,b" | join "+"}} {{if hasPrefix "sh" .Compose.Project}}shop{{end}}`,
+			context: VariableContext{
+				Container: ContainerInfo{ID: "abc123"},
+				Compose:   ComposeInfo{Project: "shop-eu", Service: "db"},
+			},
+			expected: `echo DB "shop_eu" abc a+b shop`,
+			hasError: false,
+		},
+		{
+			name:     "Invalid date",
+			input:    `echo {{.Job.Name | date "2006"}}`,
+			context:  VariableContext{},
+			expected: "",
+			hasError: true,
+		},
 		{
 			name:     "Invalid template",
 			input:    "echo {{.Invalid}",
@@ -82,3 +151,34 @@ func TestProcessVariables(t *testing.T) {
 		})
 	}
 }
+
+func TestNewVariableContext(t *testing.T) {
+	e := NewExecution()
+	e.Upstream = UpstreamInfo{Job: "dump", ExecutionID: "abc123"}
+
+	vc := NewVariableContext(&Context{Execution: e}, ContainerInfo{Name: "foo"})
+	if vc.Container.Name != "foo" {
+		t.Errorf("Expected container %q but got %q", "foo", vc.Container.Name)
+	}
+
+	if vc.Upstream != e.Upstream {
+		t.Errorf("Expected upstream %v but got %v", e.Upstream, vc.Upstream)
+	}
+
+	if vc.Execution.ID != e.ID || !vc.Execution.ScheduledTime.Equal(e.ScheduledTime) {
+		t.Errorf("Expected execution %q but got %v", e.ID, vc.Execution)
+	}
+
+	vc = NewVariableContext(nil, ContainerInfo{Labels: map[string]string{
+		ComposeProjectLabel: "shop",
+		ComposeServiceLabel: "db",
+	}})
+	if vc.Compose != (ComposeInfo{Project: "shop", Service: "db"}) {
+		t.Errorf("Expected the Compose service of the labels but got %v", vc.Compose)
+	}
+
+	vc = NewVariableContext(nil, ContainerInfo{})
+	if vc.Upstream != (UpstreamInfo{}) {
+		t.Errorf("Expected empty upstream but got %v", vc.Upstream)
+	}
+}
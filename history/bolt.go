@@ -0,0 +1,198 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	executionsBucket = []byte("executions")
+	idsBucket        = []byte("ids")
+)
+
+// openTimeout is how long we wait for the lock of the database, held by other
+// process like the daemon while it is writing
+const openTimeout = 5 * time.Second
+
+// BoltStore stores the records in a BoltDB database. The database is opened
+// on every operation, so the file is never locked for long and it can be read
+// with `chadburn history` while the daemon is running.
+type BoltStore struct {
+	Path string
+}
+
+// NewBoltStore returns a BoltStore using the given database file
+func NewBoltStore(path string) *BoltStore {
+	return &BoltStore{Path: path}
+}
+
+// Save stores the record, indexed by start time and by execution ID
+func (s *BoltStore) Save(r *Record) error {
+	js, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		executions, err := tx.CreateBucketIfNotExists(executionsBucket)
+		if err != nil {
+			return err
+		}
+
+		ids, err := tx.CreateBucketIfNotExists(idsBucket)
+		if err != nil {
+			return err
+		}
+
+		key := recordKey(r)
+		if err := executions.Put(key, js); err != nil {
+			return err
+		}
+
+		return ids.Put([]byte(r.ID), key)
+	})
+}
+
+// List returns the records matching the query, newest first
+func (s *BoltStore) List(q Query) ([]*Record, error) {
+	var result []*Record
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(executionsBucket)
+		if b == nil {
+			return nil
+		}
+
+		cur := b.Cursor()
+		for k, v := cur.Last(); k != nil; k, v = cur.Prev() {
+			r := &Record{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+
+			if !q.Since.IsZero() && r.Start.Before(q.Since) {
+				break
+			}
+
+			if !q.match(r) {
+				continue
+			}
+
+			result = append(result, r)
+			if q.Limit > 0 && len(result) >= q.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// Get returns the record with the given execution ID
+func (s *BoltStore) Get(id string) (*Record, error) {
+	var r *Record
+	err := s.view(func(tx *bolt.Tx) error {
+		ids, executions := tx.Bucket(idsBucket), tx.Bucket(executionsBucket)
+		if ids == nil || executions == nil {
+			return ErrRecordNotFound
+		}
+
+		key := ids.Get([]byte(id))
+		if key == nil {
+			return ErrRecordNotFound
+		}
+
+		v := executions.Get(key)
+		if v == nil {
+			return ErrRecordNotFound
+		}
+
+		r = &Record{}
+		return json.Unmarshal(v, r)
+	})
+
+	if err == nil && r == nil {
+		return nil, ErrRecordNotFound
+	}
+
+	return r, err
+}
+
+// Prune removes the records exceeding the retention rules
+func (s *BoltStore) Prune(maxCount int, maxAge time.Duration) error {
+	if maxCount <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		executions, ids := tx.Bucket(executionsBucket), tx.Bucket(idsBucket)
+		if executions == nil || ids == nil {
+			return nil
+		}
+
+		var records []*Record
+		cur := executions.Cursor()
+		for k, v := cur.Last(); k != nil; k, v = cur.Prev() {
+			r := &Record{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+
+			records = append(records, r)
+		}
+
+		for _, r := range expired(records, maxCount, maxAge) {
+			if err := executions.Delete(recordKey(r)); err != nil {
+				return err
+			}
+
+			if err := ids.Delete([]byte(r.ID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *BoltStore) update(fn func(*bolt.Tx) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(s.Path, 0644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (s *BoltStore) view(fn func(*bolt.Tx) error) error {
+	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		// nothing was recorded yet
+		return nil
+	}
+
+	db, err := bolt.Open(s.Path, 0644, &bolt.Options{Timeout: openTimeout, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+	return db.View(fn)
+}
+
+// recordKey sorts the records by start time, the ID avoids collisions
+func recordKey(r *Record) []byte {
+	key := make([]byte, 8, 8+len(r.ID))
+	binary.BigEndian.PutUint64(key, uint64(r.Start.UnixNano()))
+	return append(key, r.ID...)
+}
@@ -0,0 +1,155 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const recordExtension = ".json"
+
+// FileStore stores every record as a JSON document, inside of a folder per job
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore returns a FileStore rooted at the given folder
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Save writes the record to disk, the file is written atomically so it can be
+// read while the daemon is running
+func (s *FileStore) Save(r *Record) error {
+	dir := filepath.Join(s.Path, escapeName(r.Job))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(js); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(dir, recordFilename(r)))
+}
+
+// List returns the records matching the query, newest first
+func (s *FileStore) List(q Query) ([]*Record, error) {
+	pattern := filepath.Join(s.Path, "*", "*"+recordExtension)
+	if q.Job != "" {
+		pattern = filepath.Join(s.Path, escapeName(q.Job), "*"+recordExtension)
+	}
+
+	records, err := s.read(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Record
+	for _, r := range records {
+		if !q.match(r) {
+			continue
+		}
+
+		result = append(result, r)
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// Get returns the record with the given execution ID
+func (s *FileStore) Get(id string) (*Record, error) {
+	records, err := s.read(filepath.Join(s.Path, "*", "*_"+escapeName(id)+recordExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	return records[0], nil
+}
+
+// Prune removes the records exceeding the retention rules
+func (s *FileStore) Prune(maxCount int, maxAge time.Duration) error {
+	if maxCount <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	records, err := s.read(filepath.Join(s.Path, "*", "*"+recordExtension))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range expired(records, maxCount, maxAge) {
+		filename := filepath.Join(s.Path, escapeName(r.Job), recordFilename(r))
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *FileStore) read(pattern string) ([]*Record, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(files))
+	for _, filename := range files {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// pruned while we were reading
+				continue
+			}
+
+			return nil, err
+		}
+
+		r := &Record{}
+		if err := json.Unmarshal(content, r); err != nil {
+			return nil, err
+		}
+
+		records = append(records, r)
+	}
+
+	sortRecords(records)
+	return records, nil
+}
+
+func recordFilename(r *Record) string {
+	return r.Start.UTC().Format("20060102_150405.000000000") + "_" + escapeName(r.ID) + recordExtension
+}
+
+// escapeName makes a job name safe to be used as a file name
+func escapeName(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+}
@@ -0,0 +1,180 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+const (
+	// FileBackend stores every execution as a JSON document inside a folder
+	FileBackend = "file"
+	// BoltBackend stores the executions in an embedded BoltDB database
+	BoltBackend = "bolt"
+	// SQLiteBackend stores the executions in an embedded SQLite database,
+	// it needs a binary built with cgo
+	SQLiteBackend = "sqlite"
+)
+
+var (
+	ErrRecordNotFound = errors.New("unable to find an execution with the given ID.")
+	ErrUnknownBackend = errors.New("unknown history backend.")
+)
+
+// Record is the persisted summary of a finished execution
+type Record struct {
+	ID        string        `json:"id"`
+	Job       string        `json:"job"`
+	Command   string        `json:"command"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Duration  time.Duration `json:"duration"`
+	Failed    bool          `json:"failed"`
+	Skipped   bool          `json:"skipped"`
+	Error     string        `json:"error,omitempty"`
+	ExitCode  int           `json:"exit_code"`
+	Signal    string        `json:"signal,omitempty"`
+	Restarted bool          `json:"restarted,omitempty"`
+	KillError string        `json:"kill_error,omitempty"`
+	Attempts  int           `json:"attempts,omitempty"`
+	Host      string        `json:"host,omitempty"`
+	Container string        `json:"container,omitempty"`
+	Stdout    string        `json:"stdout,omitempty"`
+	Stderr    string        `json:"stderr,omitempty"`
+}
+
+// Status returns a human readable status of the execution
+func (r *Record) Status() string {
+	switch {
+	case r.Skipped:
+		return "skipped"
+	case r.Failed:
+		return "failed"
+	default:
+		return "successful"
+	}
+}
+
+// NewRecord builds a Record from a finished execution, stdout and stderr are
+// truncated to the last maxOutput bytes, a zero value keeps them complete
+func NewRecord(j core.Job, e *core.Execution, maxOutput int) *Record {
+	r := &Record{
+		ID:        e.ID,
+		Job:       j.GetName(),
+		Command:   j.GetCommand(),
+		Start:     e.StartTime(),
+		End:       e.EndTime(),
+		Duration:  e.Duration(),
+		Failed:    e.Failed(),
+		Skipped:   e.Skipped(),
+		ExitCode:  e.ExitCode,
+		Signal:    e.Signal,
+		Restarted: e.ContainerRestarted,
+		KillError: e.KillError,
+		Attempts:  e.Attempt,
+		Host:      e.Host,
+		Container: e.ContainerID,
+		Stdout:    tail(e.OutputStream.Bytes(), maxOutput),
+		Stderr:    tail(e.ErrorStream.Bytes(), maxOutput),
+	}
+
+	if e.Command != "" {
+		r.Command = e.Command
+	}
+
+	if e.Error() != nil {
+		r.Error = e.Error().Error()
+	}
+
+	return r
+}
+
+func tail(b []byte, max int) string {
+	if max > 0 && len(b) > max {
+		b = b[len(b)-max:]
+	}
+
+	return string(b)
+}
+
+// Query filters the records returned by Store.List
+type Query struct {
+	// Job returns only the executions of the job with this name
+	Job string
+	// Since returns only the executions started after this time
+	Since time.Time
+	// Limit is the maximum number of records to return, zero means no limit
+	Limit int
+}
+
+func (q *Query) match(r *Record) bool {
+	if q.Job != "" && r.Job != q.Job {
+		return false
+	}
+
+	if !q.Since.IsZero() && r.Start.Before(q.Since) {
+		return false
+	}
+
+	return true
+}
+
+// Store persists execution records
+type Store interface {
+	// Save stores a new record
+	Save(*Record) error
+	// List returns the records matching the query, newest first
+	List(Query) ([]*Record, error)
+	// Get returns the record with the given execution ID
+	Get(id string) (*Record, error)
+	// Prune keeps at most maxCount records per job and removes the records
+	// older than maxAge, zero values disable each rule
+	Prune(maxCount int, maxAge time.Duration) error
+}
+
+// New returns a Store for the given backend, stored at path
+func New(backend, path string) (Store, error) {
+	switch backend {
+	case FileBackend, "":
+		return NewFileStore(path), nil
+	case BoltBackend:
+		return NewBoltStore(path), nil
+	case SQLiteBackend:
+		return NewSQLiteStore(path), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, backend)
+	}
+}
+
+// sortRecords sorts the records newest first
+func sortRecords(records []*Record) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Start.After(records[j].Start)
+	})
+}
+
+// expired returns the records that should be removed according to the
+// retention rules, records must be sorted newest first
+func expired(records []*Record, maxCount int, maxAge time.Duration) []*Record {
+	var result []*Record
+	count := make(map[string]int)
+	limit := time.Now().Add(-maxAge)
+
+	for _, r := range records {
+		count[r.Job]++
+
+		if maxCount > 0 && count[r.Job] > maxCount {
+			result = append(result, r)
+			continue
+		}
+
+		if maxAge > 0 && r.Start.Before(limit) {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type SuiteStore struct {
+	stores map[string]Store
+}
+
+var _ = Suite(&SuiteStore{})
+
+func (s *SuiteStore) SetUpTest(c *C) {
+	dir, err := ioutil.TempDir("", "history")
+	c.Assert(err, IsNil)
+
+	s.stores = map[string]Store{
+		FileBackend:   NewFileStore(filepath.Join(dir, "files")),
+		BoltBackend:   NewBoltStore(filepath.Join(dir, "history.db")),
+		SQLiteBackend: NewSQLiteStore(filepath.Join(dir, "history.sqlite")),
+	}
+}
+
+func buildRecord(id, job string, start time.Time, failed bool) *Record {
+	return &Record{
+		ID:       id,
+		Job:      job,
+		Start:    start,
+		End:      start.Add(time.Second),
+		Duration: time.Second,
+		Failed:   failed,
+		Stdout:   "output of " + id,
+	}
+}
+
+func (s *SuiteStore) TestSaveAndGet(c *C) {
+	for backend, store := range s.stores {
+		now := time.Now()
+		c.Assert(store.Save(buildRecord("a1", "foo", now, true)), IsNil, Commentf(backend))
+
+		r, err := store.Get("a1")
+		c.Assert(err, IsNil, Commentf(backend))
+		c.Assert(r.Job, Equals, "foo")
+		c.Assert(r.Failed, Equals, true)
+		c.Assert(r.Status(), Equals, "failed")
+		c.Assert(r.Stdout, Equals, "output of a1")
+		c.Assert(r.Start.Equal(now), Equals, true)
+
+		_, err = store.Get("missing")
+		c.Assert(err, Equals, ErrRecordNotFound, Commentf(backend))
+	}
+}
+
+func (s *SuiteStore) TestGetEmpty(c *C) {
+	for backend, store := range s.stores {
+		_, err := store.Get("missing")
+		c.Assert(err, Equals, ErrRecordNotFound, Commentf(backend))
+
+		records, err := store.List(Query{})
+		c.Assert(err, IsNil, Commentf(backend))
+		c.Assert(records, HasLen, 0, Commentf(backend))
+	}
+}
+
+func (s *SuiteStore) TestList(c *C) {
+	for backend, store := range s.stores {
+		now := time.Now()
+		c.Assert(store.Save(buildRecord("a1", "foo", now.Add(-3*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("a2", "bar", now.Add(-2*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("a3", "foo", now.Add(-1*time.Hour), false)), IsNil)
+
+		records, err := store.List(Query{})
+		c.Assert(err, IsNil, Commentf(backend))
+		c.Assert(records, HasLen, 3, Commentf(backend))
+		c.Assert(records[0].ID, Equals, "a3", Commentf(backend))
+		c.Assert(records[2].ID, Equals, "a1", Commentf(backend))
+
+		records, err = store.List(Query{Job: "foo"})
+		c.Assert(err, IsNil)
+		c.Assert(records, HasLen, 2, Commentf(backend))
+
+		records, err = store.List(Query{Limit: 1})
+		c.Assert(err, IsNil)
+		c.Assert(records, HasLen, 1, Commentf(backend))
+		c.Assert(records[0].ID, Equals, "a3", Commentf(backend))
+
+		records, err = store.List(Query{Since: now.Add(-150 * time.Minute)})
+		c.Assert(err, IsNil)
+		c.Assert(records, HasLen, 2, Commentf(backend))
+	}
+}
+
+func (s *SuiteStore) TestPruneByCount(c *C) {
+	for backend, store := range s.stores {
+		now := time.Now()
+		c.Assert(store.Save(buildRecord("a1", "foo", now.Add(-3*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("a2", "foo", now.Add(-2*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("a3", "foo", now.Add(-1*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("b1", "bar", now.Add(-4*time.Hour), false)), IsNil)
+
+		c.Assert(store.Prune(2, 0), IsNil, Commentf(backend))
+
+		records, err := store.List(Query{})
+		c.Assert(err, IsNil)
+		c.Assert(records, HasLen, 3, Commentf(backend))
+
+		_, err = store.Get("a1")
+		c.Assert(err, Equals, ErrRecordNotFound, Commentf(backend))
+	}
+}
+
+func (s *SuiteStore) TestPruneByAge(c *C) {
+	for backend, store := range s.stores {
+		now := time.Now()
+		c.Assert(store.Save(buildRecord("a1", "foo", now.Add(-48*time.Hour), false)), IsNil)
+		c.Assert(store.Save(buildRecord("a2", "foo", now.Add(-time.Hour), false)), IsNil)
+
+		c.Assert(store.Prune(0, 24*time.Hour), IsNil, Commentf(backend))
+
+		records, err := store.List(Query{})
+		c.Assert(err, IsNil)
+		c.Assert(records, HasLen, 1, Commentf(backend))
+		c.Assert(records[0].ID, Equals, "a2", Commentf(backend))
+	}
+}
+
+func (s *SuiteStore) TestNewUnknownBackend(c *C) {
+	_, err := New("foo", "/tmp")
+	c.Assert(err, NotNil)
+}
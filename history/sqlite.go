@@ -0,0 +1,211 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	// registers the sqlite3 driver, it needs cgo
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteSchema creates the table of the executions, the records are stored as
+// JSON documents with the columns needed to query them
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS executions (
+	id TEXT PRIMARY KEY,
+	job TEXT NOT NULL,
+	start INTEGER NOT NULL,
+	record TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS executions_start ON executions (start, id);
+CREATE INDEX IF NOT EXISTS executions_job ON executions (job, start, id);
+`
+
+// SQLiteStore stores the records in a SQLite database. As with BoltStore the
+// database is opened on every operation, so it can be read with `chadburn
+// history` while the daemon is running.
+type SQLiteStore struct {
+	Path string
+}
+
+// NewSQLiteStore returns a SQLiteStore using the given database file
+func NewSQLiteStore(path string) *SQLiteStore {
+	return &SQLiteStore{Path: path}
+}
+
+// Save stores the record, replacing the one with the same execution ID
+func (s *SQLiteStore) Save(r *Record) error {
+	js, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return s.update(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			`INSERT OR REPLACE INTO executions (id, job, start, record) VALUES (?, ?, ?, ?)`,
+			r.ID, r.Job, r.Start.UnixNano(), string(js),
+		)
+
+		return err
+	})
+}
+
+// List returns the records matching the query, newest first
+func (s *SQLiteStore) List(q Query) ([]*Record, error) {
+	query := `SELECT record FROM executions WHERE 1 = 1`
+	var args []interface{}
+	if q.Job != "" {
+		query += ` AND job = ?`
+		args = append(args, q.Job)
+	}
+
+	if !q.Since.IsZero() {
+		query += ` AND start >= ?`
+		args = append(args, q.Since.UnixNano())
+	}
+
+	query += ` ORDER BY start DESC, id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+
+	var result []*Record
+	err := s.view(func(db *sql.DB) error {
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+		for rows.Next() {
+			r, err := scanRecord(rows)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, r)
+		}
+
+		return rows.Err()
+	})
+
+	return result, err
+}
+
+// Get returns the record with the given execution ID
+func (s *SQLiteStore) Get(id string) (*Record, error) {
+	var r *Record
+	err := s.view(func(db *sql.DB) error {
+		var err error
+		r, err = scanRecord(db.QueryRow(`SELECT record FROM executions WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return ErrRecordNotFound
+		}
+
+		return err
+	})
+
+	if err == nil && r == nil {
+		return nil, ErrRecordNotFound
+	}
+
+	return r, err
+}
+
+// Prune removes the records exceeding the retention rules, unlike the other
+// stores the records are not read, the rules are applied by the database
+func (s *SQLiteStore) Prune(maxCount int, maxAge time.Duration) error {
+	if maxCount <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	return s.update(func(tx *sql.Tx) error {
+		if maxAge > 0 {
+			limit := time.Now().Add(-maxAge).UnixNano()
+			if _, err := tx.Exec(`DELETE FROM executions WHERE start < ?`, limit); err != nil {
+				return err
+			}
+		}
+
+		if maxCount <= 0 {
+			return nil
+		}
+
+		_, err := tx.Exec(`
+			DELETE FROM executions WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (
+						PARTITION BY job ORDER BY start DESC, id DESC
+					) AS n FROM executions
+				) WHERE n > ?
+			)`, maxCount,
+		)
+
+		return err
+	})
+}
+
+func (s *SQLiteStore) update(fn func(*sql.Tx) error) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+
+	db, err := s.open("rwc")
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) view(fn func(*sql.DB) error) error {
+	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		// nothing was recorded yet
+		return nil
+	}
+
+	db, err := s.open("ro")
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+	return fn(db)
+}
+
+// open opens the database with the given mode, waiting for the lock held by
+// other process like the daemon while it is writing
+func (s *SQLiteStore) open(mode string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?mode=%s&_busy_timeout=%d", s.Path, mode, openTimeout/time.Millisecond)
+	return sql.Open("sqlite3", dsn)
+}
+
+func scanRecord(row interface{ Scan(...interface{}) error }) (*Record, error) {
+	var js string
+	if err := row.Scan(&js); err != nil {
+		return nil, err
+	}
+
+	r := &Record{}
+	return r, json.Unmarshal([]byte(js), r)
+}
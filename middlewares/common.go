@@ -1,6 +1,49 @@
 package middlewares
 
-import "reflect"
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/armon/circbuf"
+)
+
+// notificationStderrSize is the number of bytes of stderr included in the
+// notifications of failed executions
+const notificationStderrSize = 1024
+
+// OptionError is an invalid value of an option of a middleware configuration
+type OptionError struct {
+	// Option is the name of the option, eg.: "retry-delay"
+	Option string
+	msg    string
+}
+
+func (e *OptionError) Error() string {
+	return e.msg
+}
+
+func optionErrorf(option, format string, args ...interface{}) error {
+	return &OptionError{Option: option, msg: fmt.Sprintf(format, args...)}
+}
+
+// validateURL returns an error if the value of the option is not an http or
+// https URL, the value is not included as it may contain a secret
+func validateURL(option, value string) error {
+	if value == "" {
+		return optionErrorf(option, "%s is required", option)
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return optionErrorf(option, "invalid %s, must be an http or https URL", option)
+	}
+
+	return nil
+}
 
 func IsEmpty(i interface{}) bool {
 	t := reflect.TypeOf(i).Elem()
@@ -8,3 +51,76 @@ func IsEmpty(i interface{}) bool {
 
 	return reflect.DeepEqual(i, e)
 }
+
+// executionResult describes how the command of an execution finished
+func executionResult(e *core.Execution) string {
+	parts := []string{fmt.Sprintf("exit code %d", e.ExitCode)}
+	if e.Signal != "" {
+		parts = append(parts, "signal "+e.Signal)
+	}
+
+	if e.ContainerRestarted {
+		parts = append(parts, "container restarted")
+	}
+
+	if e.KillError != "" {
+		parts = append(parts, "kill failed: "+e.KillError)
+	}
+
+	if e.Attempt > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d", e.Attempt))
+	}
+
+	if e.Host != "" {
+		parts = append(parts, "host "+e.Host)
+	}
+
+	if e.ContainerID != "" {
+		parts = append(parts, "container "+e.ContainerID)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// stderrTail returns the last bytes written to the stderr of an execution
+func stderrTail(e *core.Execution) string {
+	return bufferTail(e.ErrorStream, notificationStderrSize)
+}
+
+// bufferTail returns the last size bytes of an output buffer, trimmed
+func bufferTail(b *circbuf.Buffer, size int) string {
+	if b == nil {
+		return ""
+	}
+
+	s := b.String()
+	if len(s) > size {
+		s = "..." + s[len(s)-size:]
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// notificationNote describes the decision of the notification policy, it is
+// empty for the regular notifications
+func notificationNote(ctx *core.Context) string {
+	n := ctx.Notification()
+
+	var parts []string
+	if n.Recovery {
+		parts = append(parts, "The job has recovered")
+	}
+
+	if n.Digest {
+		parts = append(parts, "Digest of the rate limited notifications")
+	}
+
+	if n.Suppressed > 0 {
+		parts = append(parts, fmt.Sprintf(
+			"%d notifications suppressed by the rate limit since %s, %d of them failures",
+			n.Suppressed, n.SuppressedSince.Format(time.RFC3339), n.SuppressedFailed,
+		))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -25,6 +25,16 @@ func NewGotify(c *GotifyConfig) core.Middleware {
 	return m
 }
 
+// Validate returns an error if the configuration is set but gotify-webhook is
+// missing or not valid
+func (c *GotifyConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return validateURL("gotify-webhook", c.GotifyWebhook)
+}
+
 type Gotify struct {
 	GotifyConfig
 }
@@ -38,7 +48,7 @@ func (m *Gotify) Run(ctx *core.Context) error {
 	err := ctx.Run()
 	ctx.Stop(err)
 
-	if ctx.Execution.Failed() || !m.GotifyOnlyOnError {
+	if ctx.ShouldNotify(m.GotifyOnlyOnError) {
 		m.pushMessage(ctx)
 	}
 
@@ -65,10 +75,17 @@ func (m *Gotify) buildMessage(ctx *core.Context) *gotifyMessage {
 	)
 
 	if ctx.Execution.Failed() {
-		msg.Message = "FAILED: " + msg.Message
+		msg.Message = "FAILED: " + msg.Message + " (" + executionResult(ctx.Execution) + ")"
+		if stderr := stderrTail(ctx.Execution); stderr != "" {
+			msg.Message += "\n\n```\n" + stderr + "\n```"
+		}
 	} else if ctx.Execution.Skipped() {
 		msg.Message = "Skipped: " + msg.Message
 	}
+
+	if note := notificationNote(ctx); note != "" {
+		msg.Message += "\n\n" + note
+	}
 	return msg
 }
 
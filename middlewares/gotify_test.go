@@ -67,3 +67,10 @@ func (s *SuiteGotify) TestRunSuccessOnError(c *C) {
 	m := NewGotify(&GotifyConfig{GotifyWebhook: ts.URL, GotifyOnlyOnError: true})
 	c.Assert(m.Run(s.ctx), IsNil)
 }
+
+func (s *SuiteGotify) TestValidate(c *C) {
+	c.Assert((&GotifyConfig{}).Validate(), IsNil)
+	c.Assert((&GotifyConfig{GotifyWebhook: "https://gotify.example.com/message?token=XXX"}).Validate(), IsNil)
+	c.Assert((&GotifyConfig{GotifyPriority: 5}).Validate(), ErrorMatches, "gotify-webhook is required")
+	c.Assert((&GotifyConfig{GotifyWebhook: "ftp://gotify.example.com"}).Validate(), ErrorMatches, "invalid gotify-webhook.*")
+}
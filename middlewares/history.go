@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/PremoWeb/Chadburn/history"
+)
+
+const (
+	defaultHistoryFilePath   = "/var/lib/chadburn/history"
+	defaultHistoryBoltPath   = "/var/lib/chadburn/history.db"
+	defaultHistorySQLitePath = "/var/lib/chadburn/history.sqlite"
+	defaultHistoryOutputSize = 64 * 1024
+
+	// historyPruneInterval is the minimum time between two prunes of the
+	// store, pruning rescans the whole store
+	historyPruneInterval = 10 * time.Minute
+)
+
+// HistoryConfig configuration for the History middleware
+type HistoryConfig struct {
+	HistoryBackend    string `gcfg:"history-backend" mapstructure:"history-backend"`
+	HistoryPath       string `gcfg:"history-path" mapstructure:"history-path"`
+	HistoryMaxCount   int    `gcfg:"history-max-count" mapstructure:"history-max-count"`
+	HistoryMaxAge     string `gcfg:"history-max-age" mapstructure:"history-max-age"`
+	HistoryOutputSize int    `gcfg:"history-output-size" mapstructure:"history-output-size"`
+}
+
+// NewHistory returns a History middleware if the given configuration is not empty
+func NewHistory(c *HistoryConfig) core.Middleware {
+	var m core.Middleware
+	if !IsEmpty(c) {
+		store, err := c.OpenStore()
+		m = &History{HistoryConfig: *c, store: store, err: err}
+	}
+
+	return m
+}
+
+// OpenStore returns the history store described by the configuration
+func (c *HistoryConfig) OpenStore() (history.Store, error) {
+	return history.New(c.HistoryBackend, c.path())
+}
+
+// MaxAge returns the parsed history-max-age, zero if not set
+func (c *HistoryConfig) MaxAge() (time.Duration, error) {
+	if c.HistoryMaxAge == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(c.HistoryMaxAge)
+}
+
+func (c *HistoryConfig) path() string {
+	if c.HistoryPath != "" {
+		return c.HistoryPath
+	}
+
+	switch c.HistoryBackend {
+	case history.BoltBackend:
+		return defaultHistoryBoltPath
+	case history.SQLiteBackend:
+		return defaultHistorySQLitePath
+	}
+
+	return defaultHistoryFilePath
+}
+
+func (c *HistoryConfig) outputSize() int {
+	if c.HistoryOutputSize == 0 {
+		return defaultHistoryOutputSize
+	}
+
+	return c.HistoryOutputSize
+}
+
+// History middleware records every execution in a persistent store
+type History struct {
+	HistoryConfig
+	store history.Store
+	err   error
+
+	mutex     sync.Mutex
+	lastPrune time.Time
+}
+
+// ContinueOnStop return allways true, we want to record skipped executions too
+func (m *History) ContinueOnStop() bool {
+	return true
+}
+
+// Run records the result of the execution, and applies the retention rules
+// every historyPruneInterval
+func (m *History) Run(ctx *core.Context) error {
+	err := ctx.Run()
+	ctx.Stop(err)
+
+	if herr := m.record(ctx); herr != nil {
+		ctx.Logger.Errorf("History error: %q", herr)
+	}
+
+	return err
+}
+
+func (m *History) record(ctx *core.Context) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	if err := m.store.Save(history.NewRecord(ctx.Job, ctx.Execution, m.outputSize())); err != nil {
+		return err
+	}
+
+	return m.prune(time.Now())
+}
+
+func (m *History) prune(now time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if now.Sub(m.lastPrune) < historyPruneInterval {
+		return nil
+	}
+
+	maxAge, err := m.MaxAge()
+	if err != nil {
+		return err
+	}
+
+	m.lastPrune = now
+	return m.store.Prune(m.HistoryMaxCount, maxAge)
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/history"
+	. "gopkg.in/check.v1"
+)
+
+type SuiteHistory struct {
+	BaseSuite
+}
+
+var _ = Suite(&SuiteHistory{})
+
+func (s *SuiteHistory) TestNewHistoryEmpty(c *C) {
+	c.Assert(NewHistory(&HistoryConfig{}), IsNil)
+}
+
+func (s *SuiteHistory) TestRunRecords(c *C) {
+	dir, err := ioutil.TempDir("", "history")
+	c.Assert(err, IsNil)
+
+	s.job.Name = "foo"
+	s.ctx.Start()
+	s.ctx.Execution.OutputStream.Write([]byte("0123456789"))
+	s.ctx.Stop(errors.New("foo"))
+
+	m := NewHistory(&HistoryConfig{HistoryPath: dir, HistoryOutputSize: 4})
+	c.Assert(m.Run(s.ctx), NotNil)
+
+	r, err := history.NewFileStore(dir).Get(s.ctx.Execution.ID)
+	c.Assert(err, IsNil)
+	c.Assert(r.Job, Equals, "foo")
+	c.Assert(r.Failed, Equals, true)
+	c.Assert(r.Error, Equals, "foo")
+	c.Assert(r.Stdout, Equals, "6789")
+}
+
+func (s *SuiteHistory) TestPruneInterval(c *C) {
+	store := &pruneCounterStore{}
+	m := &History{HistoryConfig: HistoryConfig{HistoryMaxCount: 10}, store: store}
+
+	now := time.Now()
+	c.Assert(m.prune(now), IsNil)
+	c.Assert(m.prune(now.Add(time.Minute)), IsNil)
+	c.Assert(store.prunes, Equals, 1)
+
+	c.Assert(m.prune(now.Add(historyPruneInterval)), IsNil)
+	c.Assert(store.prunes, Equals, 2)
+}
+
+// pruneCounterStore counts the calls to Prune
+type pruneCounterStore struct {
+	history.Store
+	prunes int
+}
+
+func (s *pruneCounterStore) Prune(maxCount int, maxAge time.Duration) error {
+	s.prunes++
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"net/mail"
 	"os"
 	"strings"
 
@@ -38,6 +39,43 @@ func NewMail(c *MailConfig) core.Middleware {
 	return m
 }
 
+// Validate returns an error if the configuration is set but the SMTP server
+// or the addresses are missing or not valid
+func (c *MailConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	if c.SMTPHost == "" {
+		return optionErrorf("smtp-host", "smtp-host is required")
+	}
+
+	if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+		return optionErrorf("smtp-port", "invalid smtp-port %d, must be between 1 and 65535", c.SMTPPort)
+	}
+
+	if c.EmailTo == "" {
+		return optionErrorf("email-to", "email-to is required")
+	}
+
+	for _, to := range strings.Split(c.EmailTo, ",") {
+		if _, err := mail.ParseAddress(strings.TrimSpace(to)); err != nil {
+			return optionErrorf("email-to", "invalid email-to address %q: %s", to, err)
+		}
+	}
+
+	if c.EmailFrom == "" {
+		return optionErrorf("email-from", "email-from is required")
+	}
+
+	from := (&Mail{*c}).from()
+	if _, err := mail.ParseAddress(from); err != nil {
+		return optionErrorf("email-from", "invalid email-from address %q: %s", from, err)
+	}
+
+	return nil
+}
+
 // Mail middleware delivers a email just after an execution finishes
 type Mail struct {
 	MailConfig
@@ -53,7 +91,7 @@ func (m *Mail) Run(ctx *core.Context) error {
 	err := ctx.Run()
 	ctx.Stop(err)
 
-	if ctx.Execution.Failed() || !m.MailOnlyOnError {
+	if ctx.ShouldNotify(m.MailOnlyOnError) {
 		err := m.sendMail(ctx)
 		if err != nil {
 			ctx.Logger.Errorf("Mail error: %q", err)
@@ -132,6 +170,7 @@ var mailBodyTemplate, mailSubjectTemplate *template.Template
 func init() {
 	f := map[string]interface{}{
 		"status": executionLabel,
+		"note":   notificationNote,
 	}
 
 	mailBodyTemplate = template.New("mail-body")
@@ -145,6 +184,14 @@ func init() {
 			Execution <b>{{status .Execution}}</b> in ​<b>{{.Execution.Duration}}</b>​,
 			command: ​<pre>{{.Job.GetCommand}}</pre>​
 		</p>
+		{{if not .Execution.Skipped}}
+		<p>
+			Exit code <b>{{.Execution.ExitCode}}</b>{{if .Execution.Signal}}, signal <b>{{.Execution.Signal}}</b>{{end}},
+			attempt <b>{{.Execution.Attempt}}</b>{{if .Execution.Host}}, host <b>{{.Execution.Host}}</b>{{end}}
+			{{- if .Execution.ContainerID}}, container <b>{{.Execution.ContainerID}}</b>{{end}}
+		</p>
+		{{end}}
+		{{with note .}}<p><b>{{.}}</b></p>{{end}}
   `))
 
 	template.Must(mailSubjectTemplate.Parse(
@@ -77,3 +77,29 @@ func (s *MailSuite) TestRunSuccess(c *C) {
 
 	wg.Wait()
 }
+
+func (s *MailSuite) TestValidate(c *C) {
+	c.Assert((&MailConfig{}).Validate(), IsNil)
+
+	config := MailConfig{
+		SMTPHost:  "smtp.example.com",
+		SMTPPort:  587,
+		EmailTo:   "foo@foo.com, Bar <bar@bar.com>",
+		EmailFrom: "chadburn@%s",
+	}
+
+	c.Assert(config.Validate(), IsNil)
+
+	invalid := config
+	invalid.SMTPPort = 0
+	c.Assert(invalid.Validate(), ErrorMatches, "invalid smtp-port 0.*")
+
+	invalid = config
+	invalid.EmailTo = "foo@foo.com,bar"
+	c.Assert(invalid.Validate(), ErrorMatches, `invalid email-to address "bar".*`)
+	c.Assert(invalid.Validate().(*OptionError).Option, Equals, "email-to")
+
+	invalid = config
+	invalid.SMTPHost = ""
+	c.Assert(invalid.Validate(), ErrorMatches, "smtp-host is required")
+}
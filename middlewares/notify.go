@@ -0,0 +1,210 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+const (
+	// NotifyAlways reports every execution, the default
+	NotifyAlways = "always"
+	// NotifyChange reports only the executions where the job flips from
+	// success to failure, or from failure to success
+	NotifyChange = "change"
+)
+
+// NotifyConfig configuration for the Notify middleware, the policy applies to
+// all the notification middlewares: Slack, Mail, Gotify, Teams and Webhook
+type NotifyConfig struct {
+	NotifyOn string `gcfg:"notify-on" mapstructure:"notify-on"`
+	// NotifyRateLimit is the maximum number of notifications per job in a
+	// period of time, as "<count>/<duration>", eg.: "5/1h"
+	NotifyRateLimit string `gcfg:"notify-rate-limit" mapstructure:"notify-rate-limit"`
+}
+
+// NewNotify returns a Notify middleware if the given configuration is not
+// empty
+func NewNotify(c *NotifyConfig) core.Middleware {
+	var m core.Middleware
+	if !IsEmpty(c) {
+		n := &Notify{NotifyConfig: *c, states: notifyStates}
+		n.err = n.parse()
+		m = n
+	}
+
+	return m
+}
+
+// Validate returns an error if the configuration is set but not valid
+func (c *NotifyConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return (&Notify{NotifyConfig: *c}).parse()
+}
+
+// Notify middleware sets the policy used by the notification middlewares, it
+// keeps the last known status of every job to report only the changes and
+// to rate limit the notifications
+type Notify struct {
+	NotifyConfig
+
+	limit  int
+	period time.Duration
+	err    error
+
+	states *notifyStore
+}
+
+// notifyStates is shared by all the Notify middlewares, the jobs rebuilt by a
+// reload of the configuration keep their last known status and rate limit
+var notifyStates = newNotifyStore()
+
+type notifyStore struct {
+	mutex sync.Mutex
+	jobs  map[string]*notifyState
+}
+
+func newNotifyStore() *notifyStore {
+	return &notifyStore{jobs: make(map[string]*notifyState)}
+}
+
+// get returns the state of a job, the store must be locked
+func (s *notifyStore) get(job string) *notifyState {
+	st, ok := s.jobs[job]
+	if !ok {
+		st = &notifyState{}
+		s.jobs[job] = st
+	}
+
+	return st
+}
+
+type notifyState struct {
+	known  bool
+	failed bool
+
+	// rate limit window
+	start      time.Time
+	sent       int
+	suppressed int
+	failures   int
+	since      time.Time
+}
+
+func (m *Notify) parse() error {
+	switch m.NotifyOn {
+	case "", NotifyAlways, NotifyChange:
+	default:
+		return optionErrorf("notify-on", "invalid notify-on %q, must be %q or %q", m.NotifyOn, NotifyAlways, NotifyChange)
+	}
+
+	if m.NotifyRateLimit == "" {
+		return nil
+	}
+
+	parts := strings.SplitN(m.NotifyRateLimit, "/", 2)
+	if len(parts) != 2 {
+		return optionErrorf("notify-rate-limit", "invalid notify-rate-limit %q, must be \"<count>/<duration>\"", m.NotifyRateLimit)
+	}
+
+	var err error
+	if m.limit, err = strconv.Atoi(strings.TrimSpace(parts[0])); err != nil || m.limit < 1 {
+		return optionErrorf("notify-rate-limit", "invalid notify-rate-limit %q, the count must be a positive number", m.NotifyRateLimit)
+	}
+
+	if m.period, err = time.ParseDuration(strings.TrimSpace(parts[1])); err != nil || m.period <= 0 {
+		return optionErrorf("notify-rate-limit", "invalid notify-rate-limit %q, the duration must be positive", m.NotifyRateLimit)
+	}
+
+	return nil
+}
+
+// ContinueOnStop returns always true, the policy is needed to report skipped
+// executions too
+func (m *Notify) ContinueOnStop() bool {
+	return true
+}
+
+// Run sets the notification policy of the execution
+func (m *Notify) Run(ctx *core.Context) error {
+	if m.err != nil {
+		ctx.Logger.Errorf("Notify error: %q", m.err)
+	} else {
+		ctx.SetNotifyPolicy(m)
+	}
+
+	return ctx.Run()
+}
+
+// Notify implements core.NotifyPolicy
+func (m *Notify) Notify(ctx *core.Context) core.Notification {
+	return m.notify(ctx.Job.GetName(), ctx.Execution, time.Now())
+}
+
+func (m *Notify) notify(job string, e *core.Execution, now time.Time) core.Notification {
+	m.states.mutex.Lock()
+	defer m.states.mutex.Unlock()
+
+	st := m.states.get(job)
+
+	var n core.Notification
+	if e.Skipped() {
+		// a skipped execution says nothing about the status of the job
+		n.Send = m.NotifyOn != NotifyChange
+	} else {
+		failed := e.Failed()
+
+		// the first failure is a change, the first success is not
+		changed := failed != st.failed
+		n.Recovery = st.known && st.failed && !failed
+		n.Send = m.NotifyOn != NotifyChange || changed
+
+		st.known, st.failed = true, failed
+	}
+
+	if m.limit == 0 {
+		return n
+	}
+
+	if now.Sub(st.start) >= m.period {
+		st.start, st.sent = now, 0
+
+		// the digest of the notifications suppressed in the previous period
+		// is sent with the first execution of the next one, even if the
+		// policy would not report it
+		if st.suppressed > 0 && !n.Send {
+			n.Send, n.Digest = true, true
+		}
+	}
+
+	if !n.Send {
+		return n
+	}
+
+	// recoveries are never held back, the alerts must not outlive the failure
+	if st.sent >= m.limit && !n.Recovery {
+		if st.suppressed == 0 {
+			st.since = now
+		}
+
+		st.suppressed++
+		if e.Failed() {
+			st.failures++
+		}
+
+		n.Send = false
+		return n
+	}
+
+	st.sent++
+	n.Suppressed, n.SuppressedFailed, n.SuppressedSince = st.suppressed, st.failures, st.since
+	st.suppressed, st.failures = 0, 0
+
+	return n
+}
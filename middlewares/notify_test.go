@@ -0,0 +1,176 @@
+package middlewares
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteNotify struct {
+	BaseSuite
+}
+
+var _ = Suite(&SuiteNotify{})
+
+func (s *SuiteNotify) SetUpTest(c *C) {
+	s.BaseSuite.SetUpTest(c)
+	notifyStates = newNotifyStore()
+}
+
+func (s *SuiteNotify) TestNewNotifyEmpty(c *C) {
+	c.Assert(NewNotify(&NotifyConfig{}), IsNil)
+}
+
+func (s *SuiteNotify) TestInvalidConfig(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyOn: "never"})
+	c.Assert(m.(*Notify).err, ErrorMatches, "invalid notify-on.*")
+
+	for _, limit := range []string{"5", "0/1h", "foo/1h", "5/foo", "5/-1h"} {
+		m = NewNotify(&NotifyConfig{NotifyRateLimit: limit})
+		c.Assert(m.(*Notify).err, ErrorMatches, "invalid notify-rate-limit.*")
+	}
+}
+
+func (s *SuiteNotify) TestNotifyOnChange(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyOn: NotifyChange}).(*Notify)
+	now := time.Now()
+
+	for i, expected := range []struct {
+		err      error
+		send     bool
+		recovery bool
+	}{
+		{nil, false, false},
+		{errors.New("foo"), true, false},
+		{errors.New("foo"), false, false},
+		{core.ErrSkippedExecution, false, false},
+		{nil, true, true},
+		{nil, false, false},
+	} {
+		n := m.notify("foo", s.execution(expected.err), now)
+		c.Assert(n.Send, Equals, expected.send, Commentf("execution %d", i))
+		c.Assert(n.Recovery, Equals, expected.recovery, Commentf("execution %d", i))
+	}
+
+	// the status is kept by job
+	c.Assert(m.notify("bar", s.execution(errors.New("foo")), now).Send, Equals, true)
+}
+
+func (s *SuiteNotify) TestNotifyRateLimit(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyRateLimit: "2/1h"}).(*Notify)
+	now := time.Now()
+
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now).Send, Equals, true)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now.Add(time.Minute)).Send, Equals, true)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now.Add(2*time.Minute)).Send, Equals, false)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now.Add(3*time.Minute)).Send, Equals, false)
+
+	n := m.notify("foo", s.execution(errors.New("foo")), now.Add(time.Hour))
+	c.Assert(n.Send, Equals, true)
+	c.Assert(n.Suppressed, Equals, 2)
+	c.Assert(n.SuppressedSince, Equals, now.Add(2*time.Minute))
+
+	n = m.notify("foo", s.execution(errors.New("foo")), now.Add(time.Hour+time.Minute))
+	c.Assert(n.Send, Equals, true)
+	c.Assert(n.Suppressed, Equals, 0)
+}
+
+func (s *SuiteNotify) TestNotifyDigest(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyOn: NotifyChange, NotifyRateLimit: "1/1h"}).(*Notify)
+	now := time.Now()
+
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now).Send, Equals, true)
+	c.Assert(m.notify("foo", s.execution(nil), now.Add(time.Minute)).Send, Equals, true)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now.Add(2*time.Minute)).Send, Equals, false)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now.Add(3*time.Minute)).Send, Equals, false)
+
+	// not a change, but the first execution of the next period sends the digest
+	n := m.notify("foo", s.execution(errors.New("foo")), now.Add(time.Hour))
+	c.Assert(n.Send, Equals, true)
+	c.Assert(n.Digest, Equals, true)
+	c.Assert(n.Suppressed, Equals, 1)
+	c.Assert(n.SuppressedFailed, Equals, 1)
+	c.Assert(n.SuppressedSince, Equals, now.Add(2*time.Minute))
+
+	n = m.notify("foo", s.execution(errors.New("foo")), now.Add(2*time.Hour))
+	c.Assert(n.Send, Equals, false)
+	c.Assert(n.Digest, Equals, false)
+}
+
+func (s *SuiteNotify) TestNotifyStateKeptOnRebuild(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyOn: NotifyChange}).(*Notify)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), time.Now()).Send, Equals, true)
+
+	// a reload builds a new middleware for the job
+	m = NewNotify(&NotifyConfig{NotifyOn: NotifyChange, NotifyRateLimit: "5/1h"}).(*Notify)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), time.Now()).Send, Equals, false)
+
+	n := m.notify("foo", s.execution(nil), time.Now())
+	c.Assert(n.Send, Equals, true)
+	c.Assert(n.Recovery, Equals, true)
+}
+
+func (s *SuiteNotify) TestNotifyRateLimitRecovery(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyRateLimit: "1/1h"}).(*Notify)
+	now := time.Now()
+
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now).Send, Equals, true)
+	c.Assert(m.notify("foo", s.execution(errors.New("foo")), now).Send, Equals, false)
+
+	n := m.notify("foo", s.execution(nil), now)
+	c.Assert(n.Send, Equals, true)
+	c.Assert(n.Recovery, Equals, true)
+	c.Assert(n.Suppressed, Equals, 1)
+}
+
+func (s *SuiteNotify) TestRunRecoveryOnlyOnError(c *C) {
+	m := NewNotify(&NotifyConfig{NotifyOn: NotifyChange}).(*Notify)
+	m.notify(s.job.GetName(), s.execution(errors.New("foo")), time.Now())
+
+	var called int
+	s.job.Use(m, &testNotifier{onlyOnError: true, called: &called})
+
+	s.ctx = core.NewContext(s.ctx.Scheduler, s.job, core.NewExecution())
+	s.ctx.Start()
+	c.Assert(s.ctx.Run(), IsNil)
+	c.Assert(called, Equals, 1)
+	c.Assert(notificationNote(s.ctx), Equals, "The job has recovered")
+
+	// the next success is not a change anymore
+	s.ctx = core.NewContext(s.ctx.Scheduler, s.job, core.NewExecution())
+	s.ctx.Start()
+	c.Assert(s.ctx.Run(), IsNil)
+	c.Assert(called, Equals, 1)
+}
+
+func (s *SuiteNotify) execution(err error) *core.Execution {
+	e := core.NewExecution()
+	e.Start()
+	e.Stop(err)
+
+	return e
+}
+
+// testNotifier behaves as the notification middlewares
+type testNotifier struct {
+	onlyOnError bool
+	called      *int
+}
+
+func (m *testNotifier) ContinueOnStop() bool {
+	return true
+}
+
+func (m *testNotifier) Run(ctx *core.Context) error {
+	err := ctx.Run()
+	ctx.Stop(err)
+
+	if ctx.ShouldNotify(m.onlyOnError) {
+		*m.called++
+	}
+
+	return err
+}
@@ -0,0 +1,198 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+const (
+	pingTimeout    = 10 * time.Second
+	pingOutputSize = 10240
+)
+
+// PingConfig configuration for the Ping middleware. The URLs are templates
+// rendered with the core.Context of the execution, so a single global
+// ping-url can address a different check per job, eg.:
+// https://hc-ping.com/<ping-key>/{{.Job.GetName}}
+type PingConfig struct {
+	// PingURL is a healthchecks.io style URL, "/start" and "/fail" are
+	// appended to it for the start and failure pings
+	PingURL string `gcfg:"ping-url" mapstructure:"ping-url"`
+	// PingStartURL, PingSuccessURL and PingFailURL replace the URLs derived
+	// from PingURL, eg. to use an Uptime Kuma push monitor
+	PingStartURL   string `gcfg:"ping-start-url" mapstructure:"ping-start-url"`
+	PingSuccessURL string `gcfg:"ping-success-url" mapstructure:"ping-success-url"`
+	PingFailURL    string `gcfg:"ping-fail-url" mapstructure:"ping-fail-url"`
+}
+
+// NewPing returns a Ping middleware if the given configuration is not empty
+func NewPing(c *PingConfig) core.Middleware {
+	var m core.Middleware
+	if !IsEmpty(c) {
+		p := &Ping{PingConfig: *c}
+		p.err = p.parse()
+		m = p
+	}
+
+	return m
+}
+
+// Validate returns an error if the configuration is set but not valid
+func (c *PingConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return (&Ping{PingConfig: *c}).parse()
+}
+
+// Ping middleware calls a monitoring service, as a dead man's switch, when
+// the job starts and when it succeeds or fails
+type Ping struct {
+	PingConfig
+
+	start, success, fail *template.Template
+	err                  error
+}
+
+func (m *Ping) parse() error {
+	urls := []struct {
+		name   string
+		text   string
+		suffix string
+		t      **template.Template
+	}{
+		{"ping-start-url", m.PingStartURL, "/start", &m.start},
+		{"ping-success-url", m.PingSuccessURL, "", &m.success},
+		{"ping-fail-url", m.PingFailURL, "/fail", &m.fail},
+	}
+
+	for _, u := range urls {
+		text := u.text
+		if text == "" && m.PingURL != "" {
+			text = appendURLPath(m.PingURL, u.suffix)
+		}
+
+		if text == "" {
+			continue
+		}
+
+		var err error
+		if *u.t, err = template.New(u.name).Parse(text); err != nil {
+			return optionErrorf(u.name, "invalid %s: %s", u.name, err)
+		}
+	}
+
+	return nil
+}
+
+// appendURLPath adds a suffix to the path of an URL, keeping the query
+func appendURLPath(url, suffix string) string {
+	parts := strings.SplitN(url, "?", 2)
+	parts[0] = strings.TrimRight(parts[0], "/") + suffix
+
+	return strings.Join(parts, "?")
+}
+
+// ContinueOnStop Ping is only called if the process is still running, the
+// skipped executions are not reported
+func (m *Ping) ContinueOnStop() bool {
+	return false
+}
+
+// Run pings the start URL, runs the job and pings the success or the fail
+// URL, the errors calling the URLs are logged but never fail the execution
+func (m *Ping) Run(ctx *core.Context) error {
+	if m.err != nil {
+		ctx.Logger.Errorf("Ping error: %q", m.err)
+		return ctx.Run()
+	}
+
+	m.ping(ctx, m.start, nil)
+
+	err := ctx.Run()
+	ctx.Stop(err)
+
+	if ctx.Execution.Failed() {
+		m.ping(ctx, m.fail, m.body(ctx))
+	} else if !ctx.Execution.Skipped() {
+		m.ping(ctx, m.success, m.body(ctx))
+	}
+
+	return err
+}
+
+func (m *Ping) ping(ctx *core.Context, t *template.Template, body []byte) {
+	if t == nil {
+		return
+	}
+
+	url := bytes.NewBuffer(nil)
+	if err := t.Execute(url, ctx); err != nil {
+		ctx.Logger.Errorf("Ping error rendering %s: %s", t.Name(), err)
+		return
+	}
+
+	if err := m.request(url.String(), body); err != nil {
+		ctx.Logger.Errorf("Ping error calling %q: %s", url.String(), err)
+	}
+}
+
+func (m *Ping) request(url string, body []byte) error {
+	method := http.MethodGet
+	var reader io.Reader
+	if body != nil {
+		method, reader = http.MethodPost, bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	client := &http.Client{Timeout: pingTimeout}
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return fmt.Errorf("non-2xx status code %d", r.StatusCode)
+	}
+
+	return nil
+}
+
+// body describes the execution, the monitoring services keep it in the log
+// of the check
+func (m *Ping) body(ctx *core.Context) []byte {
+	e := ctx.Execution
+
+	b := bytes.NewBufferString(executionResult(e) + "\n")
+	output := e.OutputStream
+	if e.Failed() {
+		if e.Error() != nil {
+			b.WriteString(e.Error().Error() + "\n")
+		}
+
+		output = e.ErrorStream
+	}
+
+	if tail := bufferTail(output, pingOutputSize); tail != "" {
+		b.WriteString("\n" + tail + "\n")
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuitePing struct {
+	BaseSuite
+
+	server   *httptest.Server
+	requests []string
+	bodies   []string
+}
+
+var _ = Suite(&SuitePing{})
+
+func (s *SuitePing) SetUpTest(c *C) {
+	s.BaseSuite.SetUpTest(c)
+
+	s.requests, s.bodies = nil, nil
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+		s.bodies = append(s.bodies, string(b))
+	}))
+}
+
+func (s *SuitePing) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *SuitePing) TestNewPingEmpty(c *C) {
+	c.Assert(NewPing(&PingConfig{}), IsNil)
+}
+
+func (s *SuitePing) TestRunSuccess(c *C) {
+	s.job.Name = "backup"
+	s.job.Use(NewPing(&PingConfig{PingURL: s.server.URL + "/uuid/{{.Job.GetName}}"}))
+
+	s.ctx = core.NewContext(s.ctx.Scheduler, s.job, core.NewExecution())
+	s.ctx.Start()
+	c.Assert(s.ctx.Run(), IsNil)
+
+	c.Assert(s.requests, DeepEquals, []string{
+		"GET /uuid/backup/start",
+		"POST /uuid/backup",
+	})
+
+	c.Assert(s.bodies[1], Matches, "exit code 0.*\n")
+}
+
+func (s *SuitePing) TestRunFail(c *C) {
+	s.ctx.Start()
+	s.ctx.Execution.ErrorStream.Write([]byte("disk full"))
+	s.ctx.Execution.ExitCode = 2
+	s.ctx.Stop(core.NewExitCodeError(2))
+
+	m := NewPing(&PingConfig{PingURL: s.server.URL + "/uuid?create=1"})
+	c.Assert(m.Run(s.ctx), NotNil)
+
+	c.Assert(s.requests, DeepEquals, []string{
+		"GET /uuid/start?create=1",
+		"POST /uuid/fail?create=1",
+	})
+
+	c.Assert(s.bodies[1], Matches, "(?s)exit code 2.*error non-zero exit code: 2\n\ndisk full\n")
+}
+
+func (s *SuitePing) TestRunCustomURLs(c *C) {
+	s.ctx.Start()
+	s.ctx.Stop(errors.New("foo"))
+
+	m := NewPing(&PingConfig{
+		PingSuccessURL: s.server.URL + "/push?status=up",
+		PingFailURL:    s.server.URL + "/push?status=down&msg={{.Execution.ExitCode}}",
+	})
+
+	c.Assert(m.Run(s.ctx), NotNil)
+	c.Assert(s.requests, DeepEquals, []string{"POST /push?status=down&msg=-1"})
+}
+
+func (s *SuitePing) TestRunSkipped(c *C) {
+	s.job.Use(&Overlap{OverlapConfig{NoOverlap: true}}, NewPing(&PingConfig{PingURL: s.server.URL}))
+	s.job.NotifyStart()
+	s.job.NotifyStart()
+
+	s.ctx = core.NewContext(s.ctx.Scheduler, s.job, core.NewExecution())
+	s.ctx.Start()
+	c.Assert(s.ctx.Run(), Equals, core.ErrSkippedExecution)
+	c.Assert(s.requests, HasLen, 0)
+}
+
+func (s *SuitePing) TestInvalidConfig(c *C) {
+	m := NewPing(&PingConfig{PingURL: "http://example.com/{{.Foo"})
+	c.Assert(m.(*Ping).err, ErrorMatches, "invalid ping-start-url.*")
+}
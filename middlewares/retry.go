@@ -0,0 +1,145 @@
+package middlewares
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+)
+
+const (
+	// FixedBackoff waits retry-delay between every attempt
+	FixedBackoff = "fixed"
+	// ExponentialBackoff doubles retry-delay after every attempt
+	ExponentialBackoff = "exponential"
+
+	defaultRetryDelay = 10 * time.Second
+	maxRetryDelay     = time.Hour
+)
+
+// RetryConfig configuration for the Retry middleware
+type RetryConfig struct {
+	RetryCount       int    `gcfg:"retry-count" mapstructure:"retry-count"`
+	RetryDelay       string `gcfg:"retry-delay" mapstructure:"retry-delay"`
+	RetryBackoff     string `gcfg:"retry-backoff" mapstructure:"retry-backoff"`
+	RetryJitter      bool   `gcfg:"retry-jitter" mapstructure:"retry-jitter"`
+	RetryOnExitCodes string `gcfg:"retry-on-exit-codes" mapstructure:"retry-on-exit-codes"`
+}
+
+// NewRetry returns a Retry middleware if the given configuration is not empty
+func NewRetry(c *RetryConfig) core.Middleware {
+	var m core.Middleware
+	if !IsEmpty(c) {
+		r := &Retry{RetryConfig: *c}
+		r.delay, r.exitCodes, r.err = c.parse()
+		m = r
+	}
+
+	return m
+}
+
+// Validate returns an error if the configuration is set but not valid
+func (c *RetryConfig) Validate() error {
+	_, _, err := c.parse()
+	return err
+}
+
+func (c *RetryConfig) parse() (time.Duration, map[int]bool, error) {
+	delay := defaultRetryDelay
+	if c.RetryDelay != "" {
+		var err error
+		if delay, err = time.ParseDuration(c.RetryDelay); err != nil {
+			return 0, nil, optionErrorf("retry-delay", "invalid retry-delay: %s", err)
+		}
+	}
+
+	switch c.RetryBackoff {
+	case "", FixedBackoff, ExponentialBackoff:
+	default:
+		return 0, nil, optionErrorf("retry-backoff", "invalid retry-backoff %q, must be %q or %q", c.RetryBackoff, FixedBackoff, ExponentialBackoff)
+	}
+
+	var codes map[int]bool
+	for _, s := range strings.Split(c.RetryOnExitCodes, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		code, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, nil, optionErrorf("retry-on-exit-codes", "invalid retry-on-exit-codes: %q is not a number", s)
+		}
+
+		if codes == nil {
+			codes = make(map[int]bool)
+		}
+
+		codes[code] = true
+	}
+
+	return delay, codes, nil
+}
+
+// Retry middleware runs again a failed job, inside of the same execution, so
+// the notifications are sent only with the final outcome
+type Retry struct {
+	RetryConfig
+	delay     time.Duration
+	exitCodes map[int]bool
+	err       error
+}
+
+// ContinueOnStop Retry is only called if the process is still running
+func (m *Retry) ContinueOnStop() bool {
+	return false
+}
+
+// Run sets the retry policy of the execution
+func (m *Retry) Run(ctx *core.Context) error {
+	if m.err != nil {
+		ctx.Logger.Errorf("Retry error: %q", m.err)
+	} else {
+		ctx.SetRetryPolicy(m)
+	}
+
+	return ctx.Run()
+}
+
+// Retry implements core.RetryPolicy
+func (m *Retry) Retry(attempt int, err error) (time.Duration, bool) {
+	if attempt > m.RetryCount || err == core.ErrSkippedExecution {
+		return 0, false
+	}
+
+	if m.exitCodes != nil {
+		code, ok := core.GetExitCode(err)
+		if !ok || !m.exitCodes[code] {
+			return 0, false
+		}
+	}
+
+	return m.backoff(attempt), true
+}
+
+func (m *Retry) backoff(attempt int) time.Duration {
+	delay := m.delay
+	if m.RetryBackoff == ExponentialBackoff {
+		for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+			delay *= 2
+		}
+
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+
+	if m.RetryJitter && delay > 1 {
+		// keep between the half and the whole of the delay
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	return delay
+}
@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	. "gopkg.in/check.v1"
+)
+
+type SuiteRetry struct {
+	BaseSuite
+}
+
+var _ = Suite(&SuiteRetry{})
+
+func (s *SuiteRetry) TestNewRetryEmpty(c *C) {
+	c.Assert(NewRetry(&RetryConfig{}), IsNil)
+}
+
+func (s *SuiteRetry) TestRun(c *C) {
+	m := NewRetry(&RetryConfig{RetryCount: 2})
+	c.Assert(m.Run(s.ctx), IsNil)
+	c.Assert(s.ctx.Execution.Attempts, HasLen, 1)
+}
+
+func (s *SuiteRetry) TestRetryCount(c *C) {
+	m := NewRetry(&RetryConfig{RetryCount: 2}).(*Retry)
+	c.Assert(m.err, IsNil)
+
+	_, retry := m.Retry(1, errors.New("foo"))
+	c.Assert(retry, Equals, true)
+	_, retry = m.Retry(2, errors.New("foo"))
+	c.Assert(retry, Equals, true)
+	_, retry = m.Retry(3, errors.New("foo"))
+	c.Assert(retry, Equals, false)
+}
+
+func (s *SuiteRetry) TestRetryFixed(c *C) {
+	m := NewRetry(&RetryConfig{RetryCount: 3, RetryDelay: "5s"}).(*Retry)
+
+	delay, _ := m.Retry(1, errors.New("foo"))
+	c.Assert(delay, Equals, 5*time.Second)
+	delay, _ = m.Retry(3, errors.New("foo"))
+	c.Assert(delay, Equals, 5*time.Second)
+}
+
+func (s *SuiteRetry) TestRetryExponential(c *C) {
+	m := NewRetry(&RetryConfig{
+		RetryCount:   20,
+		RetryDelay:   "1s",
+		RetryBackoff: ExponentialBackoff,
+	}).(*Retry)
+
+	delay, _ := m.Retry(1, errors.New("foo"))
+	c.Assert(delay, Equals, time.Second)
+	delay, _ = m.Retry(4, errors.New("foo"))
+	c.Assert(delay, Equals, 8*time.Second)
+	delay, _ = m.Retry(20, errors.New("foo"))
+	c.Assert(delay, Equals, maxRetryDelay)
+}
+
+func (s *SuiteRetry) TestRetryJitter(c *C) {
+	m := NewRetry(&RetryConfig{RetryCount: 1, RetryDelay: "10s", RetryJitter: true}).(*Retry)
+
+	for i := 0; i < 100; i++ {
+		delay, _ := m.Retry(1, errors.New("foo"))
+		c.Assert(delay >= 5*time.Second && delay <= 10*time.Second, Equals, true)
+	}
+}
+
+func (s *SuiteRetry) TestRetryOnExitCodes(c *C) {
+	m := NewRetry(&RetryConfig{RetryCount: 1, RetryOnExitCodes: "1, 137"}).(*Retry)
+	c.Assert(m.err, IsNil)
+
+	_, retry := m.Retry(1, core.NewExitCodeError(137))
+	c.Assert(retry, Equals, true)
+	_, retry = m.Retry(1, core.NewExitCodeError(2))
+	c.Assert(retry, Equals, false)
+	_, retry = m.Retry(1, errors.New("foo"))
+	c.Assert(retry, Equals, false)
+}
+
+func (s *SuiteRetry) TestInvalidConfig(c *C) {
+	c.Assert(NewRetry(&RetryConfig{RetryDelay: "foo"}).(*Retry).err, NotNil)
+	c.Assert(NewRetry(&RetryConfig{RetryBackoff: "foo"}).(*Retry).err, NotNil)
+	c.Assert(NewRetry(&RetryConfig{RetryOnExitCodes: "foo"}).(*Retry).err, NotNil)
+}
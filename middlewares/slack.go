@@ -31,6 +31,16 @@ func NewSlack(c *SlackConfig) core.Middleware {
 	return m
 }
 
+// Validate returns an error if the configuration is set but slack-webhook is
+// missing or not valid
+func (c *SlackConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return validateURL("slack-webhook", c.SlackWebhook)
+}
+
 // Slack middleware calls to a Slack input-hook after every execution of a job
 type Slack struct {
 	SlackConfig
@@ -47,7 +57,7 @@ func (m *Slack) Run(ctx *core.Context) error {
 	err := ctx.Run()
 	ctx.Stop(err)
 
-	if ctx.Execution.Failed() || !m.SlackOnlyOnError {
+	if ctx.ShouldNotify(m.SlackOnlyOnError) {
 		m.pushMessage(ctx)
 	}
 
@@ -84,6 +94,11 @@ func (m *Slack) buildMessage(ctx *core.Context) *slackMessage {
 			errText = ctx.Execution.Error().Error()
 		}
 
+		errText += " (" + executionResult(ctx.Execution) + ")"
+		if stderr := stderrTail(ctx.Execution); stderr != "" {
+			errText += "\n```" + stderr + "```"
+		}
+
 		msg.Attachments = append(msg.Attachments, slackAttachment{
 			Title: "Execution failed",
 			Text:  errText,
@@ -96,6 +111,13 @@ func (m *Slack) buildMessage(ctx *core.Context) *slackMessage {
 		})
 	}
 
+	if note := notificationNote(ctx); note != "" {
+		msg.Attachments = append(msg.Attachments, slackAttachment{
+			Text:  note,
+			Color: "#36A64F",
+		})
+	}
+
 	return msg
 }
 
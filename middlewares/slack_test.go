@@ -63,6 +63,23 @@ func (s *SuiteSlack) TestRunSuccessFailed(c *C) {
 	c.Assert(m.Run(s.ctx), NotNil)
 }
 
+func (s *SuiteSlack) TestRunFailedStderr(c *C) {
+	var m slackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.Unmarshal([]byte(r.FormValue(slackPayloadVar)), &m)
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Execution.ErrorStream.Write([]byte("permission denied\n"))
+	s.ctx.Stop(errors.New("foo"))
+
+	c.Assert(NewSlack(&SlackConfig{SlackWebhook: ts.URL}).Run(s.ctx), NotNil)
+	c.Assert(m.Attachments, HasLen, 1)
+	c.Assert(m.Attachments[0].Text, Equals, "foo (exit code -1, host "+s.ctx.Execution.Host+")\n```permission denied```")
+}
+
 func (s *SuiteSlack) TestRunSuccessOnError(c *C) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		c.Assert(true, Equals, false)
@@ -76,3 +93,13 @@ func (s *SuiteSlack) TestRunSuccessOnError(c *C) {
 	m := NewSlack(&SlackConfig{SlackWebhook: ts.URL, SlackOnlyOnError: true})
 	c.Assert(m.Run(s.ctx), IsNil)
 }
+
+func (s *SuiteSlack) TestValidate(c *C) {
+	c.Assert((&SlackConfig{}).Validate(), IsNil)
+	c.Assert((&SlackConfig{SlackWebhook: "https://hooks.slack.com/services/XXX"}).Validate(), IsNil)
+	c.Assert((&SlackConfig{SlackOnlyOnError: true}).Validate(), ErrorMatches, "slack-webhook is required")
+
+	err := (&SlackConfig{SlackWebhook: "hooks.slack.com/services/XXX"}).Validate()
+	c.Assert(err, ErrorMatches, "invalid slack-webhook, must be an http or https URL")
+	c.Assert(err.(*OptionError).Option, Equals, "slack-webhook")
+}
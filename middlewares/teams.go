@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
 
 	"github.com/PremoWeb/Chadburn/core"
 )
 
-var (
-	teamsAvatarURL = "https://raw.githubusercontent.com/PremoWeb/chadburn/main/static/chadburn.png"
+const (
+	// teamsOutputSize is the number of bytes of output included in the cards
+	teamsOutputSize = 4096
+
+	teamsCardContentType = "application/vnd.microsoft.card.adaptive"
+	teamsCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	teamsCardVersion     = "1.4"
 )
 
 // TeamsConfig configuration for the Teams middleware
@@ -30,7 +34,18 @@ func NewTeams(c *TeamsConfig) core.Middleware {
 	return m
 }
 
-// Teams middleware calls to a Teams input-hook after every execution of a job
+// Validate returns an error if the configuration is set but teams-webhook is
+// missing or not valid
+func (c *TeamsConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return validateURL("teams-webhook", c.TeamsWebhook)
+}
+
+// Teams middleware posts an Adaptive Card to a Teams Workflows webhook after
+// every execution of a job
 type Teams struct {
 	TeamsConfig
 }
@@ -46,7 +61,7 @@ func (m *Teams) Run(ctx *core.Context) error {
 	err := ctx.Run()
 	ctx.Stop(err)
 
-	if ctx.Execution.Failed() || !m.TeamsOnlyOnError {
+	if ctx.ShouldNotify(m.TeamsOnlyOnError) {
 		m.pushMessage(ctx)
 	}
 
@@ -60,98 +75,125 @@ func (m *Teams) pushMessage(ctx *core.Context) {
 	r, err := http.Post(m.TeamsWebhook, "application/json", reader)
 	if err != nil {
 		ctx.Logger.Errorf("Teams error calling %q error: %q", m.TeamsWebhook, err)
-	} else if r.StatusCode != 200 {
+		return
+	}
+	defer r.Body.Close()
+
+	// Workflows webhooks answer 202 Accepted
+	if r.StatusCode < 200 || r.StatusCode > 299 {
 		body, _ := ioutil.ReadAll(r.Body)
-		ctx.Logger.Errorf("Teams error non-200 status code calling %q: %v", m.TeamsWebhook, string(body))
+		ctx.Logger.Errorf("Teams error non-2xx status code calling %q: %v", m.TeamsWebhook, string(body))
 	}
 }
 
 func (m *Teams) buildMessage(ctx *core.Context) *teamsMessage {
-	msg := newTeamsMessage()
-
-	title := fmt.Sprintf(
-		"Job *%q* finished in *%s*, command `%s`",
-		ctx.Job.GetName(), ctx.Execution.Duration(), ctx.Job.GetCommand(),
-	)
-
-	s1 := teamsMessageSections{
-		ActivityTitle:    title,
-		ActivitySubtitle: "Execution successful",
-		ActivityImage:    teamsAvatarURL,
-		Facts:            make([]teamsMessageSectionFact, 0),
-		Markdown:         true,
+	e := ctx.Execution
+
+	status, color := "Execution successful", "Good"
+	if e.Failed() {
+		status, color = "Execution failed", "Attention"
+	} else if e.Skipped() {
+		status, color = "Execution skipped", "Warning"
+	}
+
+	facts := []teamsCardFact{
+		{Title: "Command", Value: ctx.Job.GetCommand()},
+		{Title: "Duration", Value: e.Duration().String()},
 	}
 
-	if ctx.Execution.Failed() {
-		msg.ThemeColor = "F35A00"
-		msg.Summary = "Execution failed"
+	if !e.Skipped() {
+		facts = append(facts, teamsCardFact{Title: "Result", Value: executionResult(e)})
+	}
 
+	if e.Failed() {
 		errText := "Unknown error"
-		if ctx.Execution.Error() != nil {
-			errText = ctx.Execution.Error().Error()
+		if e.Error() != nil {
+			errText = e.Error().Error()
 		}
 
-		s1.ActivitySubtitle = fmt.Sprintf("Execution failed: %v", errText)
-	} else if ctx.Execution.Skipped() {
-		msg.ThemeColor = "FFA500"
-		msg.Summary = "Execution skipped"
-		s1.ActivitySubtitle = "Execution skipped"
-	} else {
-		msg.ThemeColor = "7CD197"
-		msg.Summary = "Execution successful"
+		facts = append(facts, teamsCardFact{Title: "Error", Value: errText})
 	}
 
-	msg.Sections = append(msg.Sections, s1)
+	if note := notificationNote(ctx); note != "" {
+		facts = append(facts, teamsCardFact{Title: "Note", Value: note})
+	}
 
-	if isSuccess(ctx.Execution) {
-		s2 := teamsMessageSections{
-			ActivityTitle: "Execution results",
-			ActivityText:  strings.ReplaceAll(ctx.Execution.OutputStream.String(), "\n", "<br>"),
-			ActivityImage: "",
-			Facts:         nil,
-			Markdown:      true,
-		}
-		msg.Sections = append(msg.Sections, s2)
+	body := []teamsCardElement{
+		{
+			Type:   "TextBlock",
+			Text:   fmt.Sprintf("Job %q finished", ctx.Job.GetName()),
+			Size:   "Medium",
+			Weight: "Bolder",
+			Wrap:   true,
+		},
+		{Type: "TextBlock", Text: status, Color: color, Weight: "Bolder"},
+		{Type: "FactSet", Facts: facts},
 	}
 
-	return msg
-}
+	output := bufferTail(e.OutputStream, teamsOutputSize)
+	if e.Failed() {
+		output = bufferTail(e.ErrorStream, teamsOutputSize)
+	}
 
-func isSuccess(e *core.Execution) bool {
-	if e.Failed() || e.Skipped() {
-		return false
+	if output != "" && !e.Skipped() {
+		body = append(body, teamsCardElement{
+			Type:     "TextBlock",
+			Text:     output,
+			FontType: "Monospace",
+			Wrap:     true,
+		})
 	}
-	return true
-}
 
-func newTeamsMessage() *teamsMessage {
 	return &teamsMessage{
-		Type:       "MessageCard",
-		Context:    "http://schema.org/extensions",
-		ThemeColor: "0076D7",
-		Summary:    "Execution successful",
-		Sections:   make([]teamsMessageSections, 0),
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: teamsCardContentType,
+			Content: teamsCard{
+				Schema:  teamsCardSchema,
+				Type:    "AdaptiveCard",
+				Version: teamsCardVersion,
+				Body:    body,
+				MSTeams: teamsCardOptions{Width: "Full"},
+			},
+		}},
 	}
 }
 
 type teamsMessage struct {
-	Type       string                 `json:"@type"`
-	Context    string                 `json:"@context"`
-	ThemeColor string                 `json:"themeColor"`
-	Summary    string                 `json:"summary"`
-	Sections   []teamsMessageSections `json:"sections"`
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	ContentURL  *string   `json:"contentUrl"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string             `json:"$schema"`
+	Type    string             `json:"type"`
+	Version string             `json:"version"`
+	Body    []teamsCardElement `json:"body"`
+	MSTeams teamsCardOptions   `json:"msteams"`
+}
+
+type teamsCardOptions struct {
+	Width string `json:"width,omitempty"`
 }
 
-type teamsMessageSections struct {
-	ActivityTitle    string                    `json:"activityTitle"`
-	ActivitySubtitle string                    `json:"activitySubtitle"`
-	ActivityImage    string                    `json:"activityImage"`
-	ActivityText     string                    `json:"activityText"`
-	Facts            []teamsMessageSectionFact `json:"facts"`
-	Markdown         bool                      `json:"markdown"`
+type teamsCardElement struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Size     string          `json:"size,omitempty"`
+	Weight   string          `json:"weight,omitempty"`
+	Color    string          `json:"color,omitempty"`
+	FontType string          `json:"fontType,omitempty"`
+	Wrap     bool            `json:"wrap,omitempty"`
+	Facts    []teamsCardFact `json:"facts,omitempty"`
 }
 
-type teamsMessageSectionFact struct {
-	Name  string `json:"name"`
+type teamsCardFact struct {
+	Title string `json:"title"`
 	Value string `json:"value"`
 }
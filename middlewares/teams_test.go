@@ -25,7 +25,15 @@ func (s *SuiteTeams) TestRunTeamsSuccess(c *C) {
 		var m teamsMessage
 		b, _ := ioutil.ReadAll(r.Body)
 		_ = json.Unmarshal(b, &m)
-		c.Assert(m.Summary, Equals, "Execution successful")
+		c.Assert(m.Type, Equals, "message")
+		c.Assert(m.Attachments, HasLen, 1)
+		c.Assert(m.Attachments[0].ContentType, Equals, "application/vnd.microsoft.card.adaptive")
+
+		card := m.Attachments[0].Content
+		c.Assert(card.Type, Equals, "AdaptiveCard")
+		c.Assert(card.Body[1].Text, Equals, "Execution successful")
+		c.Assert(card.Body[1].Color, Equals, "Good")
+		w.WriteHeader(http.StatusAccepted)
 	}))
 
 	defer ts.Close()
@@ -42,12 +50,19 @@ func (s *SuiteTeams) TestRunTeamsSuccessFailed(c *C) {
 		var m teamsMessage
 		err := json.NewDecoder(r.Body).Decode(&m)
 		c.Assert(err, Equals, nil)
-		c.Assert(m.Summary, Equals, "Execution failed")
+
+		card := m.Attachments[0].Content
+		c.Assert(card.Body[1].Text, Equals, "Execution failed")
+		c.Assert(card.Body[1].Color, Equals, "Attention")
+		c.Assert(card.Body[2].Facts[3], DeepEquals, teamsCardFact{Title: "Error", Value: "foo"})
+		c.Assert(card.Body[3].Text, Equals, "permission denied")
+		c.Assert(card.Body[3].FontType, Equals, "Monospace")
 	}))
 
 	defer ts.Close()
 
 	s.ctx.Start()
+	s.ctx.Execution.ErrorStream.Write([]byte("permission denied\n"))
 	s.ctx.Stop(errors.New("foo"))
 
 	m := NewTeams(&TeamsConfig{TeamsWebhook: ts.URL})
@@ -67,3 +82,9 @@ func (s *SuiteTeams) TestRunTeamsSuccessOnError(c *C) {
 	m := NewTeams(&TeamsConfig{TeamsWebhook: ts.URL, TeamsOnlyOnError: true})
 	c.Assert(m.Run(s.ctx), IsNil)
 }
+
+func (s *SuiteTeams) TestValidate(c *C) {
+	c.Assert((&TeamsConfig{}).Validate(), IsNil)
+	c.Assert((&TeamsConfig{TeamsWebhook: "https://prod-00.westus.logic.azure.com/workflows/XXX"}).Validate(), IsNil)
+	c.Assert((&TeamsConfig{TeamsWebhook: "https://%zz"}).Validate(), ErrorMatches, "invalid teams-webhook.*")
+}
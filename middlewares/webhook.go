@@ -0,0 +1,388 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/PremoWeb/Chadburn/core"
+	"github.com/armon/circbuf"
+)
+
+const (
+	// WebhookSignatureHeader contains the HMAC-SHA256 of the body, signed
+	// with webhook-secret, as "sha256=<hex>"
+	WebhookSignatureHeader = "X-Chadburn-Signature"
+
+	defaultWebhookMethod     = http.MethodPost
+	defaultWebhookRetryDelay = 5 * time.Second
+	webhookTimeout           = 30 * time.Second
+)
+
+// defaultWebhookTemplate is the body sent when webhook-template is not set
+const defaultWebhookTemplate = `{
+  "job": {{json .Job.GetName}},
+  "command": {{json .Job.GetCommand}},
+  "execution": {{json .Execution.ID}},
+  "status": {{json (status .Execution)}},
+  "duration": {{json (duration .Execution)}},
+  "exit_code": {{.Execution.ExitCode}},
+  "error": {{json (error .Execution)}},
+  "stdout": {{json (tail .Execution.OutputStream 4096)}},
+  "stderr": {{json (tail .Execution.ErrorStream 4096)}},
+  "recovery": {{.Notification.Recovery}},
+  "digest": {{.Notification.Digest}},
+  "suppressed": {{.Notification.Suppressed}},
+  "suppressed_failed": {{.Notification.SuppressedFailed}}
+}`
+
+// WebhookConfig configuration for the Webhook middleware
+type WebhookConfig struct {
+	WebhookURL    string `gcfg:"webhook-url" mapstructure:"webhook-url"`
+	WebhookMethod string `gcfg:"webhook-method" mapstructure:"webhook-method"`
+	// WebhookHeaders are "Name: value" pairs, the option can be repeated
+	WebhookHeaders []string `gcfg:"webhook-header" mapstructure:"webhook-header"`
+	// WebhookSecret signs the body, see WebhookSignatureHeader
+	WebhookSecret string `gcfg:"webhook-secret" mapstructure:"webhook-secret"`
+	// WebhookTemplate is a text/template rendered with the core.Context of
+	// the execution, WebhookTemplateFile reads it from a file
+	WebhookTemplate      string `gcfg:"webhook-template" mapstructure:"webhook-template"`
+	WebhookTemplateFile  string `gcfg:"webhook-template-file" mapstructure:"webhook-template-file"`
+	WebhookOnlyOnError   bool   `gcfg:"webhook-only-on-error" mapstructure:"webhook-only-on-error"`
+	WebhookOnlyOnSuccess bool   `gcfg:"webhook-only-on-success" mapstructure:"webhook-only-on-success"`
+	WebhookRetries       int    `gcfg:"webhook-retries" mapstructure:"webhook-retries"`
+	WebhookRetryDelay    string `gcfg:"webhook-retry-delay" mapstructure:"webhook-retry-delay"`
+}
+
+// NamedWebhookConfig is a [webhook "name"] section, a webhook sent after the
+// executions of every job, or of the jobs of WebhookJobs, besides the webhooks
+// of the jobs themselves
+type NamedWebhookConfig struct {
+	WebhookConfig `mapstructure:",squash"`
+	// WebhookJobs is a comma separated list of job names, every job if empty
+	WebhookJobs string `gcfg:"webhook-jobs" mapstructure:"webhook-jobs"`
+}
+
+// Validate returns an error if the configuration is not valid, a named
+// webhook requires at least its URL
+func (c *NamedWebhookConfig) Validate() error {
+	return (&Webhook{WebhookConfig: c.WebhookConfig}).parse()
+}
+
+// Jobs returns the names of the jobs of WebhookJobs
+func (c *NamedWebhookConfig) Jobs() []string {
+	var jobs []string
+	for _, name := range strings.Split(c.WebhookJobs, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			jobs = append(jobs, name)
+		}
+	}
+
+	return jobs
+}
+
+// NewWebhook returns a Webhook middleware if the given configuration is not
+// empty
+func NewWebhook(c *WebhookConfig) core.Middleware {
+	var m core.Middleware
+	if !IsEmpty(c) {
+		m = newWebhook(c)
+	}
+
+	return m
+}
+
+func newWebhook(c *WebhookConfig) *Webhook {
+	w := &Webhook{WebhookConfig: *c}
+	w.err = w.parse()
+	return w
+}
+
+// NewWebhooks returns a Webhooks middleware with the named webhooks sorted by
+// name, if any. It is a different middleware than the Webhook of the global
+// section and of the jobs, so a job with its own webhook still sends them.
+func NewWebhooks(named map[string]*NamedWebhookConfig) core.Middleware {
+	var webhooks []*Webhook
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		w := newWebhook(&named[name].WebhookConfig)
+		w.name = name
+		if jobs := named[name].Jobs(); len(jobs) > 0 {
+			w.jobs = make(map[string]bool)
+			for _, job := range jobs {
+				w.jobs[job] = true
+			}
+		}
+
+		webhooks = append(webhooks, w)
+	}
+
+	var m core.Middleware
+	if len(webhooks) > 0 {
+		m = &Webhooks{webhooks: webhooks}
+	}
+
+	return m
+}
+
+// Validate returns an error if the configuration is set but not valid
+func (c *WebhookConfig) Validate() error {
+	if IsEmpty(c) {
+		return nil
+	}
+
+	return (&Webhook{WebhookConfig: *c}).parse()
+}
+
+// Webhook middleware sends a request, with a body built from a template, to
+// an URL after every execution of a job
+type Webhook struct {
+	WebhookConfig
+
+	// name is the one of the [webhook] section, empty for the webhook of the
+	// global section or of a job
+	name string
+	// jobs are the jobs the webhook is sent for, all of them if nil
+	jobs map[string]bool
+
+	template *template.Template
+	headers  http.Header
+	delay    time.Duration
+	err      error
+	// retries tracks the requests being retried in background
+	retries sync.WaitGroup
+}
+
+func (m *Webhook) parse() error {
+	if m.WebhookURL == "" {
+		return optionErrorf("webhook-url", "webhook-url is required")
+	}
+
+	if m.WebhookOnlyOnError && m.WebhookOnlyOnSuccess {
+		return optionErrorf("webhook-only-on-success", "webhook-only-on-error and webhook-only-on-success are exclusive")
+	}
+
+	m.delay = defaultWebhookRetryDelay
+	if m.WebhookRetryDelay != "" {
+		var err error
+		if m.delay, err = time.ParseDuration(m.WebhookRetryDelay); err != nil {
+			return optionErrorf("webhook-retry-delay", "invalid webhook-retry-delay: %s", err)
+		}
+	}
+
+	m.headers = http.Header{"Content-Type": []string{"application/json"}}
+	for _, h := range m.WebhookHeaders {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return optionErrorf("webhook-header", "invalid webhook-header %q, must be \"Name: value\"", h)
+		}
+
+		m.headers.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	text, option := m.WebhookTemplate, "webhook-template"
+	if m.WebhookTemplateFile != "" {
+		option = "webhook-template-file"
+		b, err := ioutil.ReadFile(m.WebhookTemplateFile)
+		if err != nil {
+			return optionErrorf("webhook-template-file", "cannot read webhook-template-file: %s", err)
+		}
+
+		text = string(b)
+	}
+
+	if text == "" {
+		text = defaultWebhookTemplate
+	}
+
+	var err error
+	m.template, err = template.New("webhook").Funcs(webhookFuncs).Parse(text)
+	if err != nil {
+		return optionErrorf(option, "invalid webhook template: %s", err)
+	}
+
+	return nil
+}
+
+// ContinueOnStop returns always true, we want always report the final status
+func (m *Webhook) ContinueOnStop() bool {
+	return true
+}
+
+// Run sends the request once the execution is finished
+func (m *Webhook) Run(ctx *core.Context) error {
+	err := ctx.Run()
+	ctx.Stop(err)
+
+	m.notify(ctx)
+	return err
+}
+
+// notify sends the request of a finished execution, if it passes the filters
+func (m *Webhook) notify(ctx *core.Context) {
+	if m.err != nil {
+		ctx.Logger.Errorf("Webhook%s error: %q", m.describe(), m.err)
+		return
+	}
+
+	if m.jobs != nil && !m.jobs[ctx.Job.GetName()] {
+		return
+	}
+
+	if !ctx.ShouldNotify(m.WebhookOnlyOnError) {
+		return
+	}
+
+	if m.WebhookOnlyOnSuccess && (ctx.Execution.Failed() || ctx.Execution.Skipped()) {
+		return
+	}
+
+	if err := m.send(ctx); err != nil {
+		ctx.Logger.Errorf("Webhook%s error calling %q: %s", m.describe(), m.WebhookURL, err)
+	}
+}
+
+// describe returns the name of a named webhook for the logs
+func (m *Webhook) describe() string {
+	if m.name == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" %q", m.name)
+}
+
+// Webhooks middleware sends the named webhooks after every execution, one
+// after the other
+type Webhooks struct {
+	webhooks []*Webhook
+}
+
+// ContinueOnStop returns always true, we want always report the final status
+func (m *Webhooks) ContinueOnStop() bool {
+	return true
+}
+
+// Run sends the requests once the execution is finished
+func (m *Webhooks) Run(ctx *core.Context) error {
+	err := ctx.Run()
+	ctx.Stop(err)
+
+	for _, w := range m.webhooks {
+		w.notify(ctx)
+	}
+
+	return err
+}
+
+func (m *Webhook) send(ctx *core.Context) error {
+	body := bytes.NewBuffer(nil)
+	if err := m.template.Execute(body, ctx); err != nil {
+		return fmt.Errorf("error rendering template: %s", err)
+	}
+
+	err := m.request(body.Bytes())
+	if err == nil || m.WebhookRetries <= 0 {
+		return err
+	}
+
+	// the retries don't hold the job, its next execution could start meanwhile
+	m.retries.Add(1)
+	go func() {
+		defer m.retries.Done()
+		m.retry(ctx.Logger, body.Bytes(), err)
+	}()
+
+	return nil
+}
+
+// retry sends again a request that failed with the given error, up to
+// webhook-retries times
+func (m *Webhook) retry(l core.Logger, body []byte, err error) {
+	for attempt := 1; attempt <= m.WebhookRetries; attempt++ {
+		l.Warningf("Webhook%s error calling %q: %s, retrying in %s", m.describe(), m.WebhookURL, err, m.delay)
+		time.Sleep(m.delay)
+
+		if err = m.request(body); err == nil {
+			return
+		}
+	}
+
+	l.Errorf("Webhook%s error calling %q: %s", m.describe(), m.WebhookURL, err)
+}
+
+func (m *Webhook) request(body []byte) error {
+	method := m.WebhookMethod
+	if method == "" {
+		method = defaultWebhookMethod
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), m.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for name, values := range m.headers {
+		req.Header[name] = values
+	}
+
+	if m.WebhookSecret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(m.WebhookSecret, body))
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return fmt.Errorf("non-2xx status code %d", r.StatusCode)
+	}
+
+	return nil
+}
+
+// signWebhook returns the hex encoded HMAC-SHA256 of the body
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var webhookFuncs = template.FuncMap{
+	"status": executionLabel,
+	"note":   notificationNote,
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"duration": func(e *core.Execution) string {
+		return e.Duration().String()
+	},
+	"error": func(e *core.Execution) string {
+		if e.Error() == nil {
+			return ""
+		}
+
+		return e.Error().Error()
+	},
+	"tail": func(b *circbuf.Buffer, size int) string {
+		return bufferTail(b, size)
+	},
+}
@@ -0,0 +1,246 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	"github.com/PremoWeb/Chadburn/core"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuiteWebhook struct {
+	BaseSuite
+}
+
+var _ = Suite(&SuiteWebhook{})
+
+func (s *SuiteWebhook) TestNewWebhookEmpty(c *C) {
+	c.Assert(NewWebhook(&WebhookConfig{}), IsNil)
+}
+
+func (s *SuiteWebhook) TestRunDefaultTemplate(c *C) {
+	var body map[string]interface{}
+	var contentType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		c.Assert(r.Method, Equals, http.MethodPost)
+		c.Assert(json.NewDecoder(r.Body).Decode(&body), IsNil)
+	}))
+
+	defer ts.Close()
+
+	s.job.Name = "backup"
+	s.ctx.Start()
+	s.ctx.Execution.OutputStream.Write([]byte("\"done\"\n"))
+	s.ctx.Stop(errors.New("foo"))
+
+	m := NewWebhook(&WebhookConfig{WebhookURL: ts.URL})
+	c.Assert(m.Run(s.ctx), NotNil)
+	c.Assert(contentType, Equals, "application/json")
+	c.Assert(body["job"], Equals, "backup")
+	c.Assert(body["execution"], Equals, s.ctx.Execution.ID)
+	c.Assert(body["status"], Equals, "failed")
+	c.Assert(body["error"], Equals, "foo")
+	c.Assert(body["stdout"], Equals, `"done"`)
+}
+
+func (s *SuiteWebhook) TestRunCustomTemplate(c *C) {
+	var body, auth, method string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, auth, method = string(b), r.Header.Get("Authorization"), r.Method
+	}))
+
+	defer ts.Close()
+
+	s.job.Name = "backup"
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	m := NewWebhook(&WebhookConfig{
+		WebhookURL:      ts.URL,
+		WebhookMethod:   "put",
+		WebhookHeaders:  []string{"Authorization: Bearer foo:bar"},
+		WebhookTemplate: `{{.Job.GetName}} is {{status .Execution}}`,
+	})
+
+	c.Assert(m.Run(s.ctx), IsNil)
+	c.Assert(method, Equals, http.MethodPut)
+	c.Assert(auth, Equals, "Bearer foo:bar")
+	c.Assert(body, Equals, "backup is successful")
+}
+
+func (s *SuiteWebhook) TestRunSignature(c *C) {
+	var body []byte
+	var signature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	m := NewWebhook(&WebhookConfig{WebhookURL: ts.URL, WebhookSecret: "secret"})
+	c.Assert(m.Run(s.ctx), IsNil)
+	c.Assert(signature, Equals, "sha256="+signWebhook("secret", body))
+}
+
+func (s *SuiteWebhook) TestRunOnlyOnError(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(true, Equals, false)
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	m := NewWebhook(&WebhookConfig{WebhookURL: ts.URL, WebhookOnlyOnError: true})
+	c.Assert(m.Run(s.ctx), IsNil)
+}
+
+func (s *SuiteWebhook) TestRunOnlyOnSuccess(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(true, Equals, false)
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Stop(errors.New("foo"))
+
+	m := NewWebhook(&WebhookConfig{WebhookURL: ts.URL, WebhookOnlyOnSuccess: true})
+	c.Assert(m.Run(s.ctx), NotNil)
+}
+
+func (s *SuiteWebhook) TestRunRetries(c *C) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	m := NewWebhook(&WebhookConfig{
+		WebhookURL:        ts.URL,
+		WebhookRetries:    5,
+		WebhookRetryDelay: "1ms",
+	})
+
+	c.Assert(m.Run(s.ctx), IsNil)
+	m.(*Webhook).retries.Wait()
+	c.Assert(calls, Equals, 3)
+}
+
+func (s *SuiteWebhook) TestRunRetriesInBackground(c *C) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	defer ts.Close()
+
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	m := NewWebhook(&WebhookConfig{
+		WebhookURL:        ts.URL,
+		WebhookRetries:    2,
+		WebhookRetryDelay: "1h",
+	})
+
+	// only the first request is sent before the execution ends
+	c.Assert(m.Run(s.ctx), IsNil)
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(1))
+}
+
+func (s *SuiteWebhook) TestNewWebhooksEmpty(c *C) {
+	c.Assert(NewWebhooks(nil), IsNil)
+}
+
+func (s *SuiteWebhook) TestRunNamedWebhooks(c *C) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+
+	defer ts.Close()
+
+	m := NewWebhooks(map[string]*NamedWebhookConfig{
+		"pagerduty": {WebhookConfig: WebhookConfig{WebhookURL: ts.URL + "/pagerduty", WebhookOnlyOnError: true}},
+		"discord":   {WebhookConfig: WebhookConfig{WebhookURL: ts.URL + "/discord"}, WebhookJobs: "backup, report"},
+		"ntfy":      {WebhookConfig: WebhookConfig{WebhookURL: ts.URL + "/ntfy"}, WebhookJobs: "report"},
+	})
+
+	s.job.Name = "backup"
+	s.ctx.Start()
+	s.ctx.Stop(nil)
+
+	c.Assert(m.Run(s.ctx), IsNil)
+	c.Assert(paths, DeepEquals, []string{"/discord"})
+
+	paths = nil
+	s.ctx.Execution = core.NewExecution()
+	s.ctx.Start()
+	s.ctx.Stop(errors.New("foo"))
+
+	c.Assert(m.Run(s.ctx), NotNil)
+	c.Assert(paths, DeepEquals, []string{"/discord", "/pagerduty"})
+}
+
+func (s *SuiteWebhook) TestRunJobAndGlobalWebhooks(c *C) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+
+	defer ts.Close()
+
+	// the webhook of the job replaces the global one, not the named ones
+	s.job.Use(NewWebhook(&WebhookConfig{WebhookURL: ts.URL + "/job"}))
+	s.job.Use(NewWebhook(&WebhookConfig{WebhookURL: ts.URL + "/global"}))
+	s.job.Use(NewWebhooks(map[string]*NamedWebhookConfig{
+		"chat": {WebhookConfig: WebhookConfig{WebhookURL: ts.URL + "/chat"}},
+	}))
+	c.Assert(s.job.Middlewares(), HasLen, 2)
+
+	ctx := core.NewContext(s.ctx.Scheduler, s.job, core.NewExecution())
+	ctx.Start()
+	c.Assert(ctx.Run(), IsNil)
+	c.Assert(paths, DeepEquals, []string{"/chat", "/job"})
+}
+
+func (s *SuiteWebhook) TestInvalidConfig(c *C) {
+	m := NewWebhook(&WebhookConfig{WebhookURL: "http://example.com", WebhookHeaders: []string{"foo"}})
+	c.Assert(m.(*Webhook).err, ErrorMatches, "invalid webhook-header.*")
+
+	m = NewWebhook(&WebhookConfig{WebhookURL: "http://example.com", WebhookTemplate: "{{.Foo"})
+	c.Assert(m.(*Webhook).err, ErrorMatches, "invalid webhook template.*")
+
+	m = NewWebhook(&WebhookConfig{WebhookOnlyOnError: true})
+	c.Assert(m.(*Webhook).err, ErrorMatches, "webhook-url is required")
+}
+
+func (s *SuiteWebhook) TestValidate(c *C) {
+	c.Assert((&WebhookConfig{}).Validate(), IsNil)
+	c.Assert((&NamedWebhookConfig{}).Validate(), ErrorMatches, "webhook-url is required")
+
+	err := (&WebhookConfig{WebhookURL: "http://example.com", WebhookTemplate: "{{.Foo"}).Validate()
+	c.Assert(err, FitsTypeOf, &OptionError{})
+	c.Assert(err.(*OptionError).Option, Equals, "webhook-template")
+}